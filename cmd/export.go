@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagMinUptime       = "min-uptime"
+	flagSynced          = "synced"
+	flagOutput          = "output"
+	flagPersistentPeers = "persistent-peers"
+)
+
+var (
+	exportNetwork         string
+	exportMinUptime       float64
+	exportSynced          bool
+	exportOutput          string
+	exportPersistentPeers bool
+)
+
+func getExportCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the persisted nodes of crawled networks",
+	}
+
+	addrBookCmd := &cobra.Command{
+		Use:   "addrbook [config-file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Export the nodes of a network as a Tendermint addrbook.json",
+		Long: `Export the online nodes of a network as a Tendermint addrbook.json file which
+may be placed in a node's config directory to bootstrap its peers. Nodes are
+placed in the buckets of vetted addresses, nodes without a routable IP address
+are omitted.
+
+Nodes may be filtered by a minimum 7d uptime percentage and by whether they are
+synced, i.e. report their sync status and are not catching up. With
+--persistent-peers, the nodes are written as a comma-separated list of peers in
+the form of id@ip:port instead, ready to be used as Tendermint's
+persistent_peers option.
+
+The network must be one of the configured network profiles, or any network
+crawled by the default profile if none are configured. As the database cannot
+be opened by more than one process, tmcrawl must not be running.`,
+		RunE: exportAddrBookCmdHandler,
+	}
+
+	addrBookCmd.Flags().StringVar(&exportNetwork, flagNetwork, "", "The chain ID of the network to export nodes of")
+	addrBookCmd.Flags().Float64Var(&exportMinUptime, flagMinUptime, 0, "The minimum 7d uptime percentage of exported nodes")
+	addrBookCmd.Flags().BoolVar(&exportSynced, flagSynced, false, "Only export nodes which are synced")
+	addrBookCmd.Flags().StringVar(&exportOutput, flagOutput, "", "The path of the file to write to; defaults to stdout")
+	addrBookCmd.Flags().BoolVar(&exportPersistentPeers, flagPersistentPeers, false, "Export a persistent_peers list instead of an addrbook.json")
+
+	exportCmd.AddCommand(addrBookCmd)
+
+	return exportCmd
+}
+
+func exportAddrBookCmdHandler(cmd *cobra.Command, args []string) error {
+	cfg, err := config.ParseConfig(args[0])
+	if err != nil {
+		return err
+	}
+
+	if exportMinUptime < 0 || exportMinUptime > 100 {
+		return fmt.Errorf("invalid minimum uptime: %v", exportMinUptime)
+	}
+
+	var (
+		profile string
+		network string
+		found   bool
+	)
+
+	for _, n := range cfg.NetworkConfigs() {
+		if n.ChainID == exportNetwork {
+			profile, found = n.ChainID, true
+			break
+		}
+	}
+
+	// the default profile crawls nodes of any network
+	if !found && len(cfg.Networks) == 0 {
+		network, found = exportNetwork, true
+	}
+
+	if !found {
+		return fmt.Errorf("unknown network: %s", exportNetwork)
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	filter := crawl.AddrBookFilter{MinUptime: exportMinUptime, Synced: exportSynced}
+
+	book, err := crawl.ExportAddrBook(crawl.NetworkDB(db, profile), network, filter)
+	if err != nil {
+		return err
+	}
+
+	var bz []byte
+	if exportPersistentPeers {
+		bz = []byte(book.PersistentPeers() + "\n")
+	} else {
+		bz, err = json.MarshalIndent(book, "", "\t")
+		if err != nil {
+			return fmt.Errorf("failed to encode address book: %w", err)
+		}
+
+		bz = append(bz, '\n')
+	}
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(bz)
+		return err
+	}
+
+	if err := ioutil.WriteFile(exportOutput, bz, 0644); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(os.Stderr, "exported %d nodes\n", len(book.Addrs))
+	return err
+}
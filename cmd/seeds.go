@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagNetwork       = "network"
+	flagPeers         = "peers"
+	flagAddrBook      = "addrbook"
+	flagChainRegistry = "chain-registry"
+)
+
+var (
+	seedsNetwork         string
+	seedsPeers           string
+	seedsAddrBooks       []string
+	seedsChainRegistries []string
+)
+
+func getSeedsCmd() *cobra.Command {
+	seedsCmd := &cobra.Command{
+		Use:   "seeds",
+		Short: "Manage the persisted seeds of crawled networks",
+	}
+
+	importCmd := &cobra.Command{
+		Use:   "import [config-file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Import seeds into the node pool of a network",
+		Long: `Import seeds into the persisted node pool and reseed list of a network.
+
+Seeds may be imported from a comma-separated list of peers in the form of
+id@host:port, as used by Tendermint's persistent_peers and seeds options, from
+Tendermint addrbook.json files and from Cosmos chain-registry chain.json files,
+whose seeds, persistent peers and RPC endpoints are imported. The network must
+be one of the configured network profiles, or empty if none are configured.
+
+The imported seeds are crawled once tmcrawl is (re)started. As the database
+cannot be opened by more than one process, tmcrawl must not be running.`,
+		RunE: seedsImportCmdHandler,
+	}
+
+	importCmd.Flags().StringVar(&seedsNetwork, flagNetwork, "", "The chain ID of the network profile to import seeds into")
+	importCmd.Flags().StringVar(&seedsPeers, flagPeers, "", "A comma-separated list of peers in the form of id@host:port")
+	importCmd.Flags().StringSliceVar(&seedsAddrBooks, flagAddrBook, nil, "The path to a Tendermint addrbook.json file")
+	importCmd.Flags().StringSliceVar(&seedsChainRegistries, flagChainRegistry, nil, "The path to a Cosmos chain-registry chain.json file")
+
+	seedsCmd.AddCommand(importCmd)
+
+	return seedsCmd
+}
+
+func seedsImportCmdHandler(cmd *cobra.Command, args []string) error {
+	cfg, err := config.ParseConfig(args[0])
+	if err != nil {
+		return err
+	}
+
+	var (
+		netCfg config.NetworkConfig
+		found  bool
+	)
+
+	for _, n := range cfg.NetworkConfigs() {
+		if n.ChainID == seedsNetwork {
+			netCfg, found = n, true
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("unknown network: %s", seedsNetwork)
+	}
+
+	seeds, err := crawl.LoadSeeds(config.NetworkConfig{
+		ChainID:         netCfg.ChainID,
+		Peers:           seedsPeers,
+		AddrBooks:       seedsAddrBooks,
+		ChainRegistries: seedsChainRegistries,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(seeds) == 0 {
+		return fmt.Errorf("no seeds to import; provide --%s, --%s or --%s", flagPeers, flagAddrBook, flagChainRegistry)
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	pool := crawl.LoadNodePool(crawl.NetworkDB(db, netCfg.ChainID), netCfg.ReseedSize)
+	pool.Seed(seeds)
+
+	_, err = fmt.Printf("imported %d seeds\n", len(seeds))
+	return err
+}
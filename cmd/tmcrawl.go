@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fissionlabsio/tmcrawl/config"
@@ -20,6 +24,10 @@ import (
 const (
 	logLevelJSON = "json"
 	logLevelText = "text"
+
+	// shutdownTimeout defines the maximum duration to wait for the API server
+	// and crawler to stop upon shutdown.
+	shutdownTimeout = 30 * time.Second
 )
 
 var (
@@ -40,8 +48,20 @@ from those seeds. When there are no nodes left to crawl, tmcrawl will pick a ran
 node from the known list of nodes to reseed the crawl every 'crawl_interval' seconds
 from the last attempted crawl finish.
 
-Nodes will also be periodically checked every 'recheck_interval'. If any node cannot
-be reached, it'll be removed from the known set of nodes.`,
+Nodes will also be periodically checked every 'recheck_interval'. The outcome of
+every check is recorded and used to compute each node's uptime. A node that keeps
+failing checks is marked offline and eventually removed from the known set of
+nodes, see 'offline_failures', 'offline_after' and 'purge_after'.
+
+Several networks may be crawled at once by configuring a [[networks]] profile
+for each chain ID. Every network is crawled with its own seeds, intervals, node
+pool and recheck schedule and its nodes are persisted separately.
+
+Additional node metadata is collected by probers which are enabled and configured
+in the [probers] section, e.g. [probers.rpc_audit] or [probers.services].
+
+All outbound connections may be made through a SOCKS5 or HTTP CONNECT proxy set
+via 'proxy', either globally or per network profile.`,
 	RunE: tmcrawlCmdHandler,
 }
 
@@ -50,6 +70,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logLevelJSON, "logging format; must be either json or text")
 
 	rootCmd.AddCommand(getVersionCmd())
+	rootCmd.AddCommand(getSeedsCmd())
+	rootCmd.AddCommand(getExportCmd())
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -85,28 +107,43 @@ func tmcrawlCmdHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if _, err := os.Stat(cfg.DataDir); os.IsNotExist(err) {
-		if err := os.Mkdir(cfg.DataDir, os.ModePerm); err != nil {
-			return err
-		}
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
-	// create and open key/value DB
-	db, err := db.NewBadgerDB(cfg.DataDir, "tmcrawl.db")
+	crawlers, err := crawl.NewCrawlers(cfg, db)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	crawler := crawl.NewCrawler(cfg, db)
-	go func() { crawler.Crawl() }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// start a crawler for every network profile
+	var wg sync.WaitGroup
+	for _, crawler := range crawlers {
+		wg.Add(1)
+
+		go func(crawler *crawl.Crawler) {
+			defer wg.Done()
+			crawler.Crawl(ctx)
+		}(crawler)
+	}
+
+	crawlerDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(crawlerDone)
+	}()
 
 	// create HTTP router and mount routes
 	router := mux.NewRouter()
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 	})
-	server.RegisterRoutes(db, router)
+	server.RegisterRoutes(db, cfg.NetworkConfigs(), router)
 
 	srv := &http.Server{
 		Handler:      c.Handler(router),
@@ -115,6 +152,55 @@ func tmcrawlCmdHandler(cmd *cobra.Command, args []string) error {
 		ReadTimeout:  15 * time.Second,
 	}
 
-	log.Info().Str("address", cfg.ListenAddr).Msg("starting API server...")
-	return srv.ListenAndServe()
+	srvErr := make(chan error, 1)
+
+	go func() {
+		log.Info().Str("address", cfg.ListenAddr).Msg("starting API server...")
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			srvErr <- err
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case sig := <-sigCh:
+		log.Info().Str("signal", sig.String()).Msg("shutting down...")
+
+	case err = <-srvErr:
+		log.Info().Err(err).Msg("API server failed; shutting down...")
+	}
+
+	// stop the crawler and wait for in-flight crawls to drain while the API
+	// server finishes serving in-flight requests
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+		log.Info().Err(shutdownErr).Msg("failed to gracefully stop API server")
+	}
+
+	select {
+	case <-crawlerDone:
+	case <-shutdownCtx.Done():
+		log.Info().Msg("timed out waiting for crawler to stop")
+	}
+
+	return err
+}
+
+// openDB creates the configured data directory if it does not exist and opens
+// the key/value DB within it.
+func openDB(cfg config.Config) (db.DB, error) {
+	if _, err := os.Stat(cfg.DataDir); os.IsNotExist(err) {
+		if err := os.Mkdir(cfg.DataDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	return db.NewBadgerDB(cfg.DataDir, "tmcrawl.db")
 }
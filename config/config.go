@@ -1,9 +1,11 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -16,28 +18,165 @@ var validate *validator.Validate = validator.New()
 // ErrEmptyConfigPath defines a sentinel error for an empty config path.
 var ErrEmptyConfigPath = errors.New("empty configuration file path")
 
+// ProxyDirect defines the proxy value of a network profile which connects
+// directly, regardless of the top-level proxy.
+const ProxyDirect = "direct"
+
 var (
 	defaultListenAddr           = "0.0.0.0:27758"
 	defaultCrawlInterval   uint = 15
 	defaultRecheckInterval uint = 3600
 	defaultReseedSize      uint = 100
+	defaultCrawlWorkers    uint = 8
+	defaultMaxConns        uint = 32
+	defaultOfflineFailures uint = 3
+	defaultOfflineAfter    uint = 86400
+	defaultPurgeAfter      uint = 2592000
 )
 
-// Config defines all necessary tmcrawl configuration parameters.
+// Config defines all necessary tmcrawl configuration parameters. The seeds,
+// seed sources, reseed size, intervals and proxy define the default network
+// profile. If network profiles are configured, they are crawled instead and
+// inherit any of the reseed size, intervals and proxy they do not set.
 type Config struct {
 	DataDir    string   `toml:"data_dir"`
 	ListenAddr string   `toml:"listen_addr"`
-	Seeds      []string `toml:"seeds" validate:"required,min=1"`
+	Seeds      []string `toml:"seeds"`
 	ReseedSize uint     `toml:"reseed_size"`
 	IPStackKey string   `toml:"ipstack_key" validate:"required,min=1"`
 
+	Peers           string   `toml:"peers"`
+	AddrBooks       []string `toml:"addrbooks"`
+	ChainRegistries []string `toml:"chain_registries"`
+
 	CrawlInterval   uint `toml:"crawl_interval"`
 	RecheckInterval uint `toml:"recheck_interval"`
+	CrawlWorkers    uint `toml:"crawl_workers"`
+	MaxConns        uint `toml:"max_conns"`
+
+	Proxy string `toml:"proxy"`
+
+	OfflineFailures uint `toml:"offline_failures"`
+	OfflineAfter    uint `toml:"offline_after"`
+	PurgeAfter      uint `toml:"purge_after" validate:"gtefield=OfflineAfter"`
+
+	Probers map[string]ProberConfig `toml:"probers"`
+
+	Networks []NetworkConfig `toml:"networks" validate:"unique=ChainID,dive"`
+}
+
+// ProberConfig defines the configuration of a single prober which collects
+// additional metadata of crawled nodes. Apart from the enabled flag, its
+// parameters are defined by the prober itself.
+type ProberConfig map[string]interface{}
+
+// Enabled returns true if the prober is enabled.
+func (pc ProberConfig) Enabled() bool {
+	enabled, _ := pc["enabled"].(bool)
+	return enabled
+}
+
+// Decode decodes the prober's parameters into the given value using its TOML
+// struct tags.
+func (pc ProberConfig) Decode(v interface{}) error {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(map[string]interface{}(pc)); err != nil {
+		return err
+	}
+
+	_, err := toml.Decode(buf.String(), v)
+	return err
+}
+
+// NetworkConfig defines the crawl profile of a single network. Nodes of each
+// network are crawled by a separate node pool and recheck schedule and are
+// persisted under a network-scoped prefix. Besides seed RPC addresses, seeds
+// may be imported from a comma-separated list of peers in the form of
+// id@host:port, as used by Tendermint's persistent_peers and seeds options,
+// Tendermint addrbook.json files and Cosmos chain-registry chain.json files.
+// All outbound connections of the network's crawl are made through its proxy.
+type NetworkConfig struct {
+	ChainID    string   `toml:"chain_id" validate:"required"`
+	Seeds      []string `toml:"seeds"`
+	ReseedSize uint     `toml:"reseed_size"`
+
+	Peers           string   `toml:"peers"`
+	AddrBooks       []string `toml:"addrbooks"`
+	ChainRegistries []string `toml:"chain_registries"`
+
+	CrawlInterval   uint `toml:"crawl_interval"`
+	RecheckInterval uint `toml:"recheck_interval"`
+
+	Proxy string `toml:"proxy"`
+}
+
+// NetworkConfigs returns the crawl profiles of all configured networks. If no
+// networks are configured, a single profile with an empty chain ID is returned
+// which crawls nodes of any network using the top-level parameters.
+func (c Config) NetworkConfigs() []NetworkConfig {
+	if len(c.Networks) == 0 {
+		return []NetworkConfig{
+			{
+				Seeds:           c.Seeds,
+				Peers:           c.Peers,
+				AddrBooks:       c.AddrBooks,
+				ChainRegistries: c.ChainRegistries,
+				ReseedSize:      c.ReseedSize,
+				CrawlInterval:   c.CrawlInterval,
+				RecheckInterval: c.RecheckInterval,
+				Proxy:           c.Proxy,
+			},
+		}
+	}
+
+	return c.Networks
+}
+
+// HasSeeds returns true if the network profile has any seeds or seed sources.
+func (nc NetworkConfig) HasSeeds() bool {
+	return len(nc.Seeds) > 0 || nc.Peers != "" || len(nc.AddrBooks) > 0 || len(nc.ChainRegistries) > 0
+}
+
+// ParseProxy parses a proxy URL in the form of scheme://[user:password@]host:port
+// where the scheme is either socks5, socks5h or http. Nil is returned if the
+// proxy is empty or direct, i.e. connections are made directly.
+func ParseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" || proxy == ProxyDirect {
+		return nil, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil || u.Hostname() == "" || u.Port() == "" {
+		return nil, errors.New("invalid proxy: must be in the form of scheme://[user:password@]host:port")
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+		return u, nil
+
+	default:
+		return nil, fmt.Errorf("invalid proxy: unsupported scheme %q", u.Scheme)
+	}
 }
 
-// Validate returns an error if the Config object is invalid.
+// Validate returns an error if the Config object is invalid. Every network
+// profile must have seeds or a seed source and a valid proxy, if any.
 func (c Config) Validate() error {
-	return validate.Struct(c)
+	if err := validate.Struct(c); err != nil {
+		return err
+	}
+
+	for _, n := range c.NetworkConfigs() {
+		if !n.HasSeeds() {
+			return fmt.Errorf("no seeds configured for network %q", n.ChainID)
+		}
+
+		if _, err := ParseProxy(n.Proxy); err != nil {
+			return fmt.Errorf("%w for network %q", err, n.ChainID)
+		}
+	}
+
+	return nil
 }
 
 // ParseConfig attempts to read and parse a tmcrawl config from the given file
@@ -70,6 +209,35 @@ func ParseConfig(configPath string) (Config, error) {
 	if cfg.RecheckInterval == 0 {
 		cfg.RecheckInterval = defaultRecheckInterval
 	}
+	if cfg.CrawlWorkers == 0 {
+		cfg.CrawlWorkers = defaultCrawlWorkers
+	}
+	if cfg.MaxConns == 0 {
+		cfg.MaxConns = defaultMaxConns
+	}
+	if cfg.OfflineFailures == 0 {
+		cfg.OfflineFailures = defaultOfflineFailures
+	}
+	if cfg.OfflineAfter == 0 {
+		cfg.OfflineAfter = defaultOfflineAfter
+	}
+	if cfg.PurgeAfter == 0 {
+		cfg.PurgeAfter = defaultPurgeAfter
+	}
+	for i, n := range cfg.Networks {
+		if n.ReseedSize == 0 {
+			cfg.Networks[i].ReseedSize = cfg.ReseedSize
+		}
+		if n.CrawlInterval == 0 {
+			cfg.Networks[i].CrawlInterval = cfg.CrawlInterval
+		}
+		if n.RecheckInterval == 0 {
+			cfg.Networks[i].RecheckInterval = cfg.RecheckInterval
+		}
+		if n.Proxy == "" {
+			cfg.Networks[i].Proxy = cfg.Proxy
+		}
+	}
 	if cfg.DataDir == "" {
 		cfg.DataDir = filepath.Join(os.Getenv("HOME"), ".tmcrawl")
 	}
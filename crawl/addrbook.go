@@ -0,0 +1,272 @@
+package crawl
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/p2p"
+)
+
+// Address book parameters which mirror those of Tendermint's PEX reactor.
+const (
+	addrBookBucketTypeNew = 0x01
+	addrBookBucketTypeOld = 0x02
+
+	addrBookNewBucketCount     = 256
+	addrBookNewBucketSize      = 64
+	addrBookNewBucketsPerGroup = 32
+	addrBookOldBucketCount     = 64
+	addrBookOldBucketSize      = 64
+	addrBookOldBucketsPerGroup = 4
+)
+
+type (
+	// AddrBook defines the JSON format of a Tendermint address book, i.e. the
+	// addrbook.json file of a node's config directory.
+	AddrBook struct {
+		Key   string          `json:"key"`
+		Addrs []*KnownAddress `json:"addrs"`
+	}
+
+	// KnownAddress defines an address in a Tendermint address book along with
+	// its bucket placement and dial attempts.
+	KnownAddress struct {
+		Addr        *p2p.NetAddress `json:"addr"`
+		Src         *p2p.NetAddress `json:"src"`
+		Buckets     []int           `json:"buckets"`
+		Attempts    int32           `json:"attempts"`
+		BucketType  byte            `json:"bucket_type"`
+		LastAttempt time.Time       `json:"last_attempt"`
+		LastSuccess time.Time       `json:"last_success"`
+	}
+
+	// AddrBookFilter defines the criteria nodes must meet to be exported to an
+	// address book. Only online nodes are exported. MinUptime defines the
+	// minimum 7d uptime of a node and Synced requires a node to report its sync
+	// status and not to be catching up.
+	AddrBookFilter struct {
+		MinUptime float64
+		Synced    bool
+	}
+)
+
+// ParseAddrBook parses a Tendermint addrbook.json file.
+func ParseAddrBook(bz []byte) (AddrBook, error) {
+	var book AddrBook
+	if err := json.Unmarshal(bz, &book); err != nil {
+		return AddrBook{}, fmt.Errorf("failed to decode address book: %w", err)
+	}
+
+	return book, nil
+}
+
+// Seeds returns the addresses of the address book in the form of id@ip:port.
+func (b AddrBook) Seeds() []string {
+	seeds := make([]string, 0, len(b.Addrs))
+
+	for _, ka := range b.Addrs {
+		if ka == nil || ka.Addr == nil || ka.Addr.ID == "" || ka.Addr.IP == nil {
+			continue
+		}
+
+		seeds = append(seeds, ka.Addr.String())
+	}
+
+	return seeds
+}
+
+// PersistentPeers returns the addresses of the address book as a comma-separated
+// list in the form of id@ip:port, as used by Tendermint's persistent_peers
+// option.
+func (b AddrBook) PersistentPeers() string {
+	return strings.Join(b.Seeds(), ",")
+}
+
+// Match returns true if a node meets the filter's criteria.
+func (f AddrBookFilter) Match(n Node) bool {
+	if n.Status != NodeStatusOnline || n.Uptime.Week < f.MinUptime {
+		return false
+	}
+
+	if f.Synced && (n.SyncInfo.LatestBlockHeight == 0 || n.SyncInfo.CatchingUp) {
+		return false
+	}
+
+	return true
+}
+
+// ExportAddrBook returns a Tendermint address book of all persisted nodes that
+// match the filter. If network is non-empty, only nodes of that network are
+// included.
+func ExportAddrBook(db db.DB, network string, filter AddrBookFilter) (AddrBook, error) {
+	nodes := []Node{}
+
+	var err error
+	db.IteratePrefix(NodeKeyPrefix, func(_, v []byte) bool {
+		node := new(Node)
+
+		err = node.Unmarshal(v)
+		if err != nil {
+			return true
+		}
+
+		if (network == "" || node.Network == network) && filter.Match(*node) {
+			nodes = append(nodes, *node)
+		}
+
+		return false
+	})
+
+	if err != nil {
+		return AddrBook{}, err
+	}
+
+	return NewAddrBook(nodes), nil
+}
+
+// NewAddrBook returns a Tendermint address book of the given nodes with a new
+// random key. Nodes are placed in the buckets of vetted addresses as they were
+// reached by the crawler, and in the buckets of new addresses once those are
+// full. Nodes without a routable IP address or whose buckets are full are
+// omitted.
+func NewAddrBook(nodes []Node) AddrBook {
+	book := AddrBook{
+		Key:   crypto.CRandHex(24),
+		Addrs: []*KnownAddress{},
+	}
+
+	nodes = append([]Node{}, nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	oldBuckets := make([]int, addrBookOldBucketCount)
+	newBuckets := make([]int, addrBookNewBucketCount)
+
+	for _, n := range nodes {
+		addr := nodeNetAddress(n)
+		if addr == nil || !addr.Routable() {
+			continue
+		}
+
+		ka := &KnownAddress{Addr: addr, Src: addr}
+		ka.LastAttempt, _ = time.Parse(time.RFC3339, n.LastSync)
+
+		if i := book.oldBucket(addr); oldBuckets[i] < addrBookOldBucketSize {
+			oldBuckets[i]++
+
+			ka.BucketType = addrBookBucketTypeOld
+			ka.Buckets = []int{i}
+			ka.LastSuccess, _ = time.Parse(time.RFC3339, n.LastSeen)
+		} else if i := book.newBucket(addr, addr); newBuckets[i] < addrBookNewBucketSize {
+			newBuckets[i]++
+
+			ka.BucketType = addrBookBucketTypeNew
+			ka.Buckets = []int{i}
+		} else {
+			continue
+		}
+
+		book.Addrs = append(book.Addrs, ka)
+	}
+
+	return book
+}
+
+// nodeNetAddress returns the P2P network address of a node. Nil is returned if
+// the node's address is not an IP address or its P2P port is invalid.
+func nodeNetAddress(n Node) *p2p.NetAddress {
+	ip := net.ParseIP(n.Address)
+	if ip == nil || n.ID == "" {
+		return nil
+	}
+
+	port, err := strconv.ParseUint(n.P2PPort, 10, 16)
+	if err != nil {
+		return nil
+	}
+
+	addr := p2p.NewNetAddressIPPort(ip, uint16(port))
+	addr.ID = p2p.ID(n.ID)
+
+	return addr
+}
+
+// newBucket returns the index of the new bucket of an address as computed by
+// Tendermint's address book.
+func (b AddrBook) newBucket(addr, src *p2p.NetAddress) int {
+	data1 := []byte(b.Key + addrBookGroupKey(addr) + addrBookGroupKey(src))
+	hash64 := binary.BigEndian.Uint64(doubleSha256(data1)) % addrBookNewBucketsPerGroup
+
+	data2 := append([]byte(b.Key+addrBookGroupKey(src)), uint64Bytes(hash64)...)
+	return int(binary.BigEndian.Uint64(doubleSha256(data2)) % addrBookNewBucketCount)
+}
+
+// oldBucket returns the index of the old bucket of an address as computed by
+// Tendermint's address book.
+func (b AddrBook) oldBucket(addr *p2p.NetAddress) int {
+	data1 := []byte(b.Key + addr.String())
+	hash64 := binary.BigEndian.Uint64(doubleSha256(data1)) % addrBookOldBucketsPerGroup
+
+	data2 := append([]byte(b.Key+addrBookGroupKey(addr)), uint64Bytes(hash64)...)
+	return int(binary.BigEndian.Uint64(doubleSha256(data2)) % addrBookOldBucketCount)
+}
+
+// addrBookGroupKey returns the network group of a routable address as computed
+// by Tendermint's address book: the /16 of IPv4 addresses (including those
+// embedded in IPv6 addresses) and the /32, or /36 for he.net, of IPv6
+// addresses.
+func addrBookGroupKey(na *p2p.NetAddress) string {
+	if ipv4 := na.IP.To4(); ipv4 != nil {
+		return (&net.IPNet{IP: na.IP, Mask: net.CIDRMask(16, 32)}).String()
+	}
+
+	if na.RFC6145() || na.RFC6052() {
+		ip := na.IP[12:16]
+		return (&net.IPNet{IP: ip, Mask: net.CIDRMask(16, 32)}).String()
+	}
+
+	if na.RFC3964() {
+		ip := na.IP[2:7]
+		return (&net.IPNet{IP: ip, Mask: net.CIDRMask(16, 32)}).String()
+	}
+
+	if na.RFC4380() {
+		// teredo tunnels have the last 4 bytes as the v4 address XOR 0xff
+		ip := net.IP(make([]byte, 4))
+		for i, b := range na.IP[12:16] {
+			ip[i] = b ^ 0xff
+		}
+
+		return (&net.IPNet{IP: ip, Mask: net.CIDRMask(16, 32)}).String()
+	}
+
+	bits := 32
+	heNet := &net.IPNet{IP: net.ParseIP("2001:470::"), Mask: net.CIDRMask(32, 128)}
+	if heNet.Contains(na.IP) {
+		bits = 36
+	}
+
+	return (&net.IPNet{IP: na.IP, Mask: net.CIDRMask(bits, 128)}).String()
+}
+
+func doubleSha256(bz []byte) []byte {
+	first := sha256.Sum256(bz)
+	second := sha256.Sum256(first[:])
+
+	return second[:]
+}
+
+func uint64Bytes(x uint64) []byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, x)
+
+	return bz
+}
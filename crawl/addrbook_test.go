@@ -0,0 +1,88 @@
+package crawl_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/p2p/pex"
+)
+
+func TestExportAddrBook(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{}, bdb)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	nodes := []crawl.Node{
+		// healthy and synced
+		{ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6", Address: "1.2.3.4", P2PPort: "26656", Network: "cosmoshub-3", Status: crawl.NodeStatusOnline, Uptime: crawl.Uptime{Week: 100}, SyncInfo: crawl.SyncInfo{LatestBlockHeight: 100}, LastSync: now, LastSeen: now},
+		// healthy and synced on another network
+		{ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", Address: "5.6.7.8", P2PPort: "26656", Network: "chain-0", Status: crawl.NodeStatusOnline, Uptime: crawl.Uptime{Week: 100}, SyncInfo: crawl.SyncInfo{LatestBlockHeight: 100}, LastSync: now, LastSeen: now},
+		// catching up
+		{ID: "ba3bacc714817218562f743178228f23678b2873", Address: "9.10.11.12", P2PPort: "26656", Network: "cosmoshub-3", Status: crawl.NodeStatusOnline, Uptime: crawl.Uptime{Week: 100}, SyncInfo: crawl.SyncInfo{LatestBlockHeight: 50, CatchingUp: true}, LastSync: now, LastSeen: now},
+		// low uptime
+		{ID: "c2d9a5f1e3b4a6c8d0e2f4a6b8c0d2e4f6a8b0c2", Address: "13.14.15.16", P2PPort: "26656", Network: "cosmoshub-3", Status: crawl.NodeStatusOnline, Uptime: crawl.Uptime{Week: 50}, SyncInfo: crawl.SyncInfo{LatestBlockHeight: 100}, LastSync: now, LastSeen: now},
+		// offline
+		{ID: "d3e0b6a2f4c5b7d9e1f3a5b7c9d1e3f5a7b9c1d3", Address: "17.18.19.20", P2PPort: "26656", Network: "cosmoshub-3", Status: crawl.NodeStatusOffline, Uptime: crawl.Uptime{Week: 100}, SyncInfo: crawl.SyncInfo{LatestBlockHeight: 100}, LastSync: now, LastSeen: now},
+		// unroutable
+		{ID: "e4f1c7b3a5d6c8e0f2a4b6c8d0e2f4a6b8c0d2e4", Address: "127.0.0.1", P2PPort: "26656", Network: "cosmoshub-3", Status: crawl.NodeStatusOnline, Uptime: crawl.Uptime{Week: 100}, SyncInfo: crawl.SyncInfo{LatestBlockHeight: 100}, LastSync: now, LastSeen: now},
+	}
+
+	for _, n := range nodes {
+		require.NoError(t, c.SaveNode(n))
+	}
+
+	book, err := crawl.ExportAddrBook(bdb, "cosmoshub-3", crawl.AddrBookFilter{MinUptime: 90, Synced: true})
+	require.NoError(t, err)
+	require.Len(t, book.Addrs, 1)
+	require.Equal(t, "5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656", book.PersistentPeers())
+
+	book, err = crawl.ExportAddrBook(bdb, "cosmoshub-3", crawl.AddrBookFilter{})
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656",
+		"ba3bacc714817218562f743178228f23678b2873@9.10.11.12:26656",
+		"c2d9a5f1e3b4a6c8d0e2f4a6b8c0d2e4f6a8b0c2@13.14.15.16:26656",
+	}, ","), book.PersistentPeers())
+
+	book, err = crawl.ExportAddrBook(bdb, "", crawl.AddrBookFilter{MinUptime: 90, Synced: true})
+	require.NoError(t, err)
+	require.Len(t, book.Addrs, 2)
+
+	// the exported address book must be loadable by Tendermint
+	dir, err := ioutil.TempDir("", "tmcrawl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bz, err := json.Marshal(book)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "addrbook.json")
+	require.NoError(t, ioutil.WriteFile(path, bz, 0644))
+
+	tmBook := pex.NewAddrBook(path, true)
+	require.NoError(t, tmBook.Start())
+	defer tmBook.Stop()
+
+	require.Equal(t, 2, tmBook.Size())
+
+	for _, ka := range book.Addrs {
+		require.Len(t, ka.Buckets, 1)
+		require.True(t, tmBook.HasAddress(ka.Addr))
+
+		addr, err := p2p.NewNetAddressString(ka.Addr.String())
+		require.NoError(t, err)
+		require.True(t, tmBook.IsGood(addr))
+	}
+}
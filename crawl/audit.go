@@ -0,0 +1,142 @@
+package crawl
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+)
+
+// RPCAuditProberName defines the name of the RPC audit prober.
+const RPCAuditProberName = "rpc_audit"
+
+// unsafeRPCRoutes defines the RPC routes which are only exposed when a node
+// runs with unsafe RPC enabled.
+var unsafeRPCRoutes = map[string]bool{
+	"dial_seeds":                true,
+	"dial_peers":                true,
+	"unsafe_flush_mempool":      true,
+	"unsafe_start_cpu_profiler": true,
+	"unsafe_stop_cpu_profiler":  true,
+	"unsafe_write_heap_profile": true,
+}
+
+// safeRPCChecks defines the unsafe RPC routes which can be called without side
+// effects as they reject a request without arguments.
+var safeRPCChecks = []string{"dial_seeds", "dial_peers"}
+
+func init() {
+	RegisterProberFactory(RPCAuditProberName, func(_ config.ProberConfig) (Prober, error) {
+		return rpcAuditProber{}, nil
+	})
+}
+
+// rpcAuditProber implements a Prober which audits the RPC routes a node exposes
+// for unsafe routes.
+type rpcAuditProber struct{}
+
+func (rpcAuditProber) Name() string {
+	return RPCAuditProberName
+}
+
+func (rpcAuditProber) Probe(ctx context.Context, target ProbeTarget, node *Node) error {
+	if target.RPCAddress == "" {
+		return nil
+	}
+
+	exposure, err := AuditRPC(ctx, target.dialer(), target.RPCAddress)
+	if err != nil {
+		return err
+	}
+
+	node.RPCExposure = exposure
+	return nil
+}
+
+// rpcRouteRe matches the route names of the endpoint listing a node's RPC
+// serves at its root path.
+var rpcRouteRe = regexp.MustCompile(`href="//[^/"]*/([^?"]+)`)
+
+// RPCExposure defines the RPC routes a Tendermint node exposes publicly as
+// found by its last RPC audit.
+type RPCExposure struct {
+	Routes       []string `json:"routes" yaml:"routes"`
+	UnsafeRoutes []string `json:"unsafe_routes" yaml:"unsafe_routes"`
+	LastAudit    string   `json:"last_audit" yaml:"last_audit"`
+}
+
+// Unsafe returns true if any unsafe RPC route is exposed.
+func (e RPCExposure) Unsafe() bool {
+	return len(e.UnsafeRoutes) > 0
+}
+
+// AuditRPC returns the RPC exposure of a node given its RPC address. The
+// exposed routes are taken from the endpoint listing served at the root path.
+// Unsafe routes that reject requests without arguments are additionally called
+// without any, so they are found even if the listing is not served. No route
+// with side effects is ever called. All requests are made via the dialer. An
+// error is returned if the endpoint listing cannot be queried.
+func AuditRPC(ctx context.Context, dialer Dialer, nodeRPCAddr string) (RPCExposure, error) {
+	client := newHTTPClient(ctx, dialer, clientTimeout)
+	exposure := RPCExposure{Routes: []string{}, UnsafeRoutes: []string{}}
+
+	body, _, err := httpGet(client, nodeRPCAddr+"/")
+	if err != nil {
+		return exposure, err
+	}
+
+	routes := make(map[string]bool)
+	for _, m := range rpcRouteRe.FindAllStringSubmatch(body, -1) {
+		routes[m[1]] = true
+	}
+
+	for _, route := range safeRPCChecks {
+		if routes[route] {
+			continue
+		}
+
+		// an exposed route rejects the request with a JSON-RPC error
+		body, status, err := httpGet(client, nodeRPCAddr+"/"+route)
+		if err == nil && status != http.StatusNotFound && strings.Contains(body, `"jsonrpc"`) {
+			routes[route] = true
+		}
+	}
+
+	for route := range routes {
+		exposure.Routes = append(exposure.Routes, route)
+
+		if unsafeRPCRoutes[route] {
+			exposure.UnsafeRoutes = append(exposure.UnsafeRoutes, route)
+		}
+	}
+
+	sort.Strings(exposure.Routes)
+	sort.Strings(exposure.UnsafeRoutes)
+
+	exposure.LastAudit = time.Now().UTC().Format(time.RFC3339)
+	return exposure, nil
+}
+
+// httpGet performs a GET request and returns the response body, truncated to
+// maxResponseSize bytes, and status code.
+func httpGet(client *http.Client, url string) (string, int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer resp.Body.Close()
+
+	bz, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return string(bz), resp.StatusCode, nil
+}
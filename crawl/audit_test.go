@@ -0,0 +1,46 @@
+package crawl_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRPC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprintf(w, `<html><body><br>Available endpoints:<br>`)
+		fmt.Fprintf(w, `<a href="//%[1]s/status">//%[1]s/status</a></br>`, r.Host)
+		fmt.Fprintf(w, `<a href="//%[1]s/unsafe_flush_mempool">//%[1]s/unsafe_flush_mempool</a></br>`, r.Host)
+		fmt.Fprintf(w, `<br>Endpoints that require arguments:<br>`)
+		fmt.Fprintf(w, `<a href="//%[1]s/block?height=_">//%[1]s/block?height=_</a></br>`, r.Host)
+		fmt.Fprintf(w, `</body></html>`)
+	})
+
+	// a route missing from the listing is found by calling it without arguments
+	mux.HandleFunc("/dial_peers", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":"","error":{"code":-32603,"message":"Internal error","data":"no peers provided"}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	exposure, err := crawl.AuditRPC(context.Background(), &net.Dialer{}, srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, []string{"block", "dial_peers", "status", "unsafe_flush_mempool"}, exposure.Routes)
+	require.Equal(t, []string{"dial_peers", "unsafe_flush_mempool"}, exposure.UnsafeRoutes)
+	require.True(t, exposure.Unsafe())
+	require.NotEmpty(t, exposure.LastAudit)
+}
@@ -1,226 +1,895 @@
 package crawl
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/fissionlabsio/tmcrawl/config"
 	"github.com/fissionlabsio/tmcrawl/db"
-	"github.com/harwoeck/ipstack"
 	"github.com/rs/zerolog/log"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/p2p"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 )
 
-const defaultP2PPort = "26656"
+const (
+	defaultP2PPort   = "26656"
+	defaultRPCPort   = "26657"
+	defaultRPCScheme = "http"
 
-// Crawler implements the Tendermint p2p network crawler.
+	// idleWorkerWait defines the duration a crawl worker waits before checking
+	// the node pool again when all remaining nodes are in-flight.
+	idleWorkerWait = 100 * time.Millisecond
+
+	// recheckPollInterval defines the maximum duration between polls of the
+	// recheck schedule for due nodes.
+	recheckPollInterval = time.Minute
+)
+
+// Crawler implements the Tendermint p2p network crawler. A Crawler crawls the
+// nodes of a single network profile using its own node pool and recheck
+// schedule within the network's keyspace.
 type Crawler struct {
-	db       db.DB
-	seeds    []string
-	pool     *NodePool
-	ipClient *ipstack.Client
+	db         db.DB
+	network    string
+	seeds      []string
+	pool       *NodePool
+	validators *ValidatorSets
+	ipClient   *ipstackClient
+	dialer     Dialer
+	conns      connLimiter
+	nodeKey    crypto.PrivKey
+	probers    []Prober
+	runs       crawlRunTracker
+	genesisMtx sync.Mutex
 
 	crawlInterval   uint
 	recheckInterval uint
+	crawlWorkers    uint
+	reseedSize      uint
+
+	offlineFailures uint
+	offlineAfter    time.Duration
+	purgeAfter      time.Duration
 }
 
-func NewCrawler(cfg config.Config, db db.DB) *Crawler {
-	return &Crawler{
-		db:              db,
-		seeds:           cfg.Seeds,
-		crawlInterval:   cfg.CrawlInterval,
-		recheckInterval: cfg.RecheckInterval,
-		pool:            NewNodePool(cfg.ReseedSize),
-		ipClient:        ipstack.NewClient(cfg.IPStackKey, false, 5),
+// NewCrawler returns a Crawler for the given network profile. Nodes are
+// persisted in the network's keyspace of the provided DB. A non-empty chain ID
+// restricts the crawl to nodes of that network. The Crawler connects directly
+// until its dialer is set.
+func NewCrawler(cfg config.Config, netCfg config.NetworkConfig, db db.DB) *Crawler {
+	ndb := NetworkDB(db, netCfg.ChainID)
+	dialer := &net.Dialer{}
+
+	c := &Crawler{
+		db:              ndb,
+		network:         netCfg.ChainID,
+		seeds:           netCfg.Seeds,
+		crawlInterval:   netCfg.CrawlInterval,
+		recheckInterval: netCfg.RecheckInterval,
+		reseedSize:      netCfg.ReseedSize,
+		crawlWorkers:    cfg.CrawlWorkers,
+		offlineFailures: cfg.OfflineFailures,
+		offlineAfter:    time.Duration(cfg.OfflineAfter) * time.Second,
+		purgeAfter:      time.Duration(cfg.PurgeAfter) * time.Second,
+		pool:            LoadNodePool(ndb, netCfg.ReseedSize),
+		validators:      NewValidatorSets(),
+		ipClient:        newIPStackClient(cfg.IPStackKey, dialer),
+		dialer:          dialer,
+		conns:           newConnLimiter(cfg.MaxConns),
+		nodeKey:         ed25519.GenPrivKey(),
 	}
+
+	c.probers = builtinProbers(c)
+	return c
 }
 
-// Crawl starts a blocking process in which a random node is selected from the
-// node pool and crawled. For each successful crawl, it'll be persisted or updated
-// and its peers will be added to the node pool if they do not already exist.
-// This process continues indefinitely until all nodes are exhausted from the pool.
-// When the pool is empty and after crawlInterval seconds since the last complete
-// crawl, a random set of nodes from the DB are added to reseed the pool.
-func (c *Crawler) Crawl() {
+// SetDialer sets the Dialer by which all outbound connections of the Crawler
+// are made, including its geolocation queries and those of its probers.
+func (c *Crawler) SetDialer(d Dialer) {
+	c.dialer = d
+	c.ipClient = newIPStackClient(c.ipClient.accessKey, d)
+}
+
+// NewCrawlers returns a Crawler for every configured network profile with all
+// enabled probers registered, its seeds imported from its seed sources and its
+// outbound connections made through its proxy. The crawlers share the outbound
+// connection limit, the crawler's node key and the probers. An error is
+// returned if the probers cannot be created, the seeds cannot be imported or a
+// proxy is invalid.
+func NewCrawlers(cfg config.Config, db db.DB) ([]*Crawler, error) {
+	probers, err := NewProbers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	netCfgs := cfg.NetworkConfigs()
+	crawlers := make([]*Crawler, len(netCfgs))
+
+	for i, netCfg := range netCfgs {
+		seeds, err := LoadSeeds(netCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		dialer, err := NewDialer(netCfg.Proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		crawlers[i] = NewCrawler(cfg, netCfg, db)
+		crawlers[i].seeds = seeds
+
+		migrated, dropped, err := MigrateNodeKeys(crawlers[i].db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate node keys: %w", err)
+		}
+
+		if migrated > 0 || dropped > 0 {
+			log.Info().Str("network", netCfg.ChainID).Int("migrated", migrated).Int("dropped", dropped).Msg("migrated nodes keyed by address")
+		}
+		crawlers[i].SetDialer(dialer)
+
+		for _, p := range probers {
+			crawlers[i].RegisterProber(p)
+		}
+
+		if i > 0 {
+			crawlers[i].conns = crawlers[0].conns
+			crawlers[i].nodeKey = crawlers[0].nodeKey
+		}
+	}
+
+	return crawlers, nil
+}
+
+// Crawl starts a blocking process in which crawlWorkers concurrent workers
+// select random nodes from the node pool and crawl them. For each successful
+// crawl, it'll be persisted or updated and its peers will be added to the node
+// pool if they do not already exist. This process continues until all nodes
+// are exhausted from the pool. When the pool is empty and after crawlInterval
+// seconds since the last complete crawl, the pool is reseeded from the reseed
+// list and a random set of healthy nodes from the DB. Every crawl of the pool is
+// recorded as a crawl run along with its statistics. Crawl returns once the
+// context is canceled and all in-flight crawls and the recheck process have
+// stopped.
+func (c *Crawler) Crawl(ctx context.Context) {
 	// seed the pool with the initial set of seeds before crawling
 	c.pool.Seed(c.seeds)
 
-	go c.RecheckNodes()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.RecheckNodes(ctx)
+	}()
 
 	for {
-		nodeRPCAddr, ok := c.pool.RandomNode()
-		for ok {
-			c.CrawlNode(nodeRPCAddr)
-			c.pool.DeleteNode(nodeRPCAddr)
+		c.validators.Reset()
+
+		c.startCrawlRun(time.Now())
+		c.crawlPool(ctx)
+		c.finishCrawlRun(time.Now())
+
+		log.Info().Str("network", c.network).Uint("duration", c.crawlInterval).Msg("waiting until next crawl attempt...")
+
+		select {
+		case <-ctx.Done():
+			log.Info().Str("network", c.network).Msg("stopping crawler...")
+			return
+
+		case <-time.After(time.Duration(c.crawlInterval) * time.Second):
+			c.reseed()
+		}
+	}
+}
+
+// reseed adds the nodes of the reseed list along with up to reseedSize random
+// healthy nodes to the node pool. The nodes are sampled from the healthy node
+// index so that persisted nodes do not need to be decoded.
+func (c *Crawler) reseed() {
+	c.pool.Reseed()
+
+	if c.reseedSize == 0 {
+		return
+	}
 
-			nodeRPCAddr, ok = c.pool.RandomNode()
+	sample := make([]string, 0, c.reseedSize)
+	healthy := 0
+
+	c.db.IteratePrefix(HealthyKeyPrefix, func(k, v []byte) bool {
+		nodeAddr := p2p.IDAddressString(p2p.ID(k[len(HealthyKeyPrefix):]), string(v))
+		healthy++
+
+		// reservoir sample healthy nodes so each is equally likely to be chosen
+		if len(sample) < cap(sample) {
+			sample = append(sample, nodeAddr)
+		} else if i := rand.Intn(healthy); i < len(sample) {
+			sample[i] = nodeAddr
 		}
 
-		log.Info().Uint("duration", c.crawlInterval).Msg("waiting until next crawl attempt...")
-		time.Sleep(time.Duration(c.crawlInterval) * time.Second)
-		c.pool.Reseed()
+		return false
+	})
+
+	log.Info().Str("network", c.network).Int("healthy", healthy).Int("reseeded", len(sample)).Msg("reseeding node pool...")
+
+	for _, nodeAddr := range sample {
+		c.pool.AddNode(nodeAddr)
 	}
 }
 
-// CrawlNode performs the main crawling functionality for a Tendermint node. It
-// accepts a node RPC address and attempts to ping that node's P2P address by
-// using the RPC address and the default P2P port of 26656. If the P2P address
-// cannot be reached, the node is deleted if it exists in the database. Otherwise,
-// we attempt to get additional metadata aboout the node via it's RPC address
-// and its set of peers. For every peer that doesn't exist in the node pool, it
-// is added.
-func (c *Crawler) CrawlNode(nodeRPCAddr string) {
-	host := parseHostname(nodeRPCAddr)
-	nodeP2PAddr := fmt.Sprintf("%s:%s", host, defaultP2PPort)
+// crawlPool starts crawlWorkers workers that drain the node pool. It blocks
+// until the pool is empty and no crawls are in-flight or until the context is
+// canceled and all in-flight crawls have returned.
+func (c *Crawler) crawlPool(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := uint(0); i < c.crawlWorkers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			c.crawlWorker(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// crawlWorker claims and crawls nodes from the node pool until the pool is
+// idle or the context is canceled. If all remaining nodes are in-flight, the
+// worker waits for other workers as they may add newly discovered peers to the
+// pool. A node whose crawl is interrupted by cancellation is left in the pool.
+func (c *Crawler) crawlWorker(ctx context.Context) {
+	for ctx.Err() == nil {
+		nodeAddr, ok := c.pool.ClaimNode()
+		if !ok {
+			if c.pool.Idle() {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(idleWorkerWait):
+			}
+
+			continue
+		}
+
+		c.CrawlNode(ctx, nodeAddr)
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.pool.ReleaseNode(nodeAddr)
+	}
+}
+
+// CrawlNode crawls a Tendermint node by its RPC address or its P2P address in
+// the form of id@host:port. The node is probed with a P2P handshake, whose
+// outcome is recorded in its probe history, and a failed handshake applies the
+// node's retention policy. Otherwise, its NodeInfo, status and net info are
+// recorded, its role is derived from its network's validator set and it is run
+// through all probers before it is persisted. Peers found via PEX or its net
+// info are added to the node pool unless they are already known.
+func (c *Crawler) CrawlNode(ctx context.Context, nodeAddr string) {
+	expectedID, host, rpcScheme, rpcPort, p2pPort := parseNodeAddr(nodeAddr)
+	c.attemptCrawlRun()
+
+	var (
+		status    *ctypes.ResultStatus
+		statusErr error
+		statusRTT time.Duration
+	)
+
+	if p2pPort == "" {
+		start := time.Now()
+		status, statusErr = c.getStatus(ctx, nodeAddr)
+		statusRTT = time.Since(start)
+
+		if statusErr == nil {
+			p2pPort = parseListenPort(status.NodeInfo.ListenAddr)
+		}
+		if p2pPort == "" {
+			p2pPort = defaultP2PPort
+		}
+	}
+
+	nodeP2PAddr := net.JoinHostPort(host, p2pPort)
+	now := time.Now().UTC()
 
 	node := Node{
-		Address:  host,
-		RPCPort:  parsePort(nodeRPCAddr),
-		P2PPort:  defaultP2PPort,
-		LastSync: time.Now().UTC().Format(time.RFC3339),
+		Address:   host,
+		RPCScheme: rpcScheme,
+		RPCPort:   rpcPort,
+		P2PPort:   p2pPort,
+		Status:    NodeStatusOnline,
+		LastSync:  now.Format(time.RFC3339),
+	}
+
+	log.Debug().Str("p2p_address", nodeP2PAddr).Msg("performing p2p handshake...")
+	exchange, err := c.exchangeP2P(ctx, nodeP2PAddr)
+	if err != nil {
+		// the node's availability is unknown if the crawl was interrupted
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Msg("failed to handshake with node")
+
+		result := NewProbeResult(now, exchange.latency, false)
+		result.ConnectRTT = durationMillis(exchange.connectRTT)
+		result.SetError(ProbeStageP2P, err)
+
+		c.runs.update(func(r *CrawlRun) {
+			r.Failed++
+			r.Errors[ProbeStageP2P]++
+		})
+
+		if err := c.recordFailedProbe(node, now, result); err != nil {
+			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Msg("failed to record failed probe")
+		}
+
+		return
 	}
 
-	log.Debug().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("pinging node...")
-	if ok := PingAddress(nodeP2PAddr, 5); !ok {
-		log.Info().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to ping node; deleting...")
+	nodeInfo := exchange.nodeInfo
+
+	result := NewProbeResult(now, exchange.latency, true)
+	result.ConnectRTT = durationMillis(exchange.connectRTT)
+
+	if expectedID != "" && expectedID != string(nodeInfo.ID()) {
+		log.Info().Str("p2p_address", nodeP2PAddr).Str("expected_id", expectedID).Str("id", string(nodeInfo.ID())).Msg("removing node replaced at its address")
+
+		if err := c.deleteReplacedNode(expectedID, nodeP2PAddr); err != nil {
+			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Msg("failed to delete node")
+		}
+	}
+
+	// nodes of other networks are not part of this network's crawl
+	if c.network != "" && nodeInfo.Network != c.network {
+		log.Info().Str("p2p_address", nodeP2PAddr).Str("network", nodeInfo.Network).Msg("skipping node of another network")
+		c.runs.update(func(r *CrawlRun) { r.Skipped++ })
 
 		if err := c.DeleteNodeIfExist(node); err != nil {
-			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to delete node")
+			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Msg("failed to delete node")
 		}
 
 		return
 	}
 
-	loc, err := c.GetGeolocation(host)
-	if err != nil {
-		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to get node geolocation")
-	} else {
-		node.Location = loc
+	node.Moniker = nodeInfo.Moniker
+	node.ID = string(nodeInfo.ID())
+	node.Network = nodeInfo.Network
+	node.Version = nodeInfo.Version
+	node.TxIndex = nodeInfo.Other.TxIndex
+	node.Channels = nodeInfo.Channels.String()
+	node.ListenAddr = nodeInfo.ListenAddr
+	node.ProtocolVersion = protocolVersionFromNodeInfo(nodeInfo)
+
+	// a node discovered via its P2P address may advertise its RPC address
+	if node.RPCPort == "" {
+		node.RPCPort = parsePort(nodeInfo.Other.RPCAddress)
+	}
+	if node.RPCPort == "" {
+		node.RPCPort = defaultRPCPort
 	}
 
-	client := newRPCClient(nodeRPCAddr)
+	// keep the scheme the node's RPC address was seeded with, e.g. https
+	if prev, err := GetNode(c.db, node.ID); err == nil && node.RPCScheme == "" && prev.RPCPort == node.RPCPort {
+		node.RPCScheme = prev.RPCScheme
+	}
+	if node.RPCScheme == "" {
+		node.RPCScheme = defaultRPCScheme
+	}
 
-	status, err := client.Status()
-	if err != nil {
-		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to get node status")
-	} else {
-		node.Moniker = status.NodeInfo.Moniker
-		node.ID = string(status.NodeInfo.ID())
-		node.Network = status.NodeInfo.Network
-		node.Version = status.NodeInfo.Version
-		node.TxIndex = status.NodeInfo.Other.TxIndex
+	nodeRPCAddr := node.RPCAddress()
 
-		netInfo, err := client.NetInfo()
-		if err != nil {
-			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to get node net info")
-			return
+	for _, addr := range exchange.addrs {
+		peer := Node{
+			Address: addr.IP.String(),
+			P2PPort: strconv.Itoa(int(addr.Port)),
+		}
+
+		// only add peer to the pool if we haven't (re)discovered it at this address
+		if !c.db.Has(peer.AddressKey()) {
+			log.Debug().Str("p2p_address", nodeP2PAddr).Str("peer_p2p_address", addr.String()).Msg("adding PEX peer to node pool")
+			c.pool.AddNode(addr.String())
+		}
+	}
+
+	// hold a connection slot for the duration of the RPC queries as the client
+	// does not keep connections alive between sequential requests
+	c.conns.acquire()
+	defer c.conns.release()
+
+	client := newRPCClient(ctx, c.dialer, nodeRPCAddr)
+
+	// peers remains nil if the node's net info cannot be queried, in which case
+	// its previously persisted peer graph edges are kept
+	var peers []Peer
+
+	// the status may have already been queried to discover the P2P port
+	if status == nil && statusErr == nil {
+		start := time.Now()
+		status, statusErr = client.Status()
+		statusRTT = time.Since(start)
+	}
+
+	if statusErr == nil {
+		result.StatusRTT = durationMillis(statusRTT)
+		node.SyncInfo = syncInfoFromStatus(status)
+
+		// refresh the network's validator set from a node that is not catching up
+		if !status.SyncInfo.CatchingUp && c.validators.Claim(node.Network) {
+			if vals, err := client.Validators(nil); err != nil {
+				log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to get validator set")
+				c.validators.Unclaim(node.Network)
+			} else {
+				c.validators.Set(node.Network, vals)
+			}
 		}
 
+		node.ValidatorAddress = status.ValidatorInfo.Address.String()
+		node.Role, node.VotingPower = c.validators.Role(node.Network, node.ValidatorAddress, status.ValidatorInfo.VotingPower)
+	}
+
+	if statusErr != nil {
+		log.Info().Err(statusErr).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to get node status")
+		result.SetError(ProbeStageStatus, statusErr)
+	} else if netInfo, netInfoRTT, err := getNetInfo(client); err != nil {
+		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to get node net info")
+		result.SetError(ProbeStageNetInfo, err)
+	} else {
+		result.NetInfoRTT = durationMillis(netInfoRTT)
+		node.NetInfo = netInfoFromResult(netInfo)
+		peers = make([]Peer, 0, len(netInfo.Peers))
+
 		for _, p := range netInfo.Peers {
-			peerRPCPort := parsePort(p.NodeInfo.Other.RPCAddress)
-			peerRPCAddress := fmt.Sprintf("http://%s:%s", p.RemoteIP, peerRPCPort)
+			edge := NewPeer(p, now)
+			peers = append(peers, edge)
+
+			peerP2PAddress := p2p.IDAddressString(p2p.ID(edge.ID), edge.P2PAddress())
 			peer := Node{
-				Address: p.RemoteIP,
+				Address: edge.Address,
+				P2PPort: edge.P2PPort,
 			}
 
-			// only add peer to the pool if we haven't (re)discovered it
-			if !c.db.Has(peer.Key()) {
-				log.Debug().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Str("peer_rpc_address", peerRPCAddress).Msg("adding peer to node pool")
-				c.pool.AddNode(peerRPCAddress)
+			// only add peer to the pool if we haven't (re)discovered it at this address
+			if !c.db.Has(peer.AddressKey()) {
+				log.Debug().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Str("peer_p2p_address", peerP2PAddress).Msg("adding peer to node pool")
+				c.pool.AddNode(peerP2PAddress)
 			}
 		}
 	}
 
+	target := ProbeTarget{
+		ID:         node.ID,
+		Network:    node.Network,
+		Host:       host,
+		P2PAddress: nodeP2PAddr,
+		Dialer:     c.dialer,
+	}
+
+	// probers only query the node's RPC if its status could be queried
+	if statusErr == nil {
+		target.RPCAddress = nodeRPCAddr
+	}
+
+	for _, p := range c.probers {
+		if err := p.Probe(ctx, target, &node); err != nil {
+			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Str("prober", p.Name()).Msg("failed to probe node")
+		}
+	}
+
+	// the RPC queries and probes of an interrupted crawl fail, so the node is
+	// left as it was persisted rather than overwritten with partial results
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err := c.recordProbe(&node, now, result); err != nil {
+		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to record probe")
+	}
+
+	discovered := !c.db.Has(NodeKey(node.ID))
+
 	if err := c.SaveNode(node); err != nil {
 		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to encode node")
-	} else {
-		log.Info().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("successfully crawled and persisted node")
+		c.runs.update(func(r *CrawlRun) { r.Failed++ })
+		return
+	}
+
+	c.runs.update(func(r *CrawlRun) {
+		r.Succeeded++
+		if discovered {
+			r.Discovered++
+		}
+		if result.ErrorStage != "" {
+			r.Errors[result.ErrorStage]++
+		}
+	})
+
+	if peers != nil {
+		if err := c.SavePeers(node.ID, peers); err != nil {
+			log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to persist node peers")
+		}
+	}
+
+	log.Info().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("successfully crawled and persisted node")
+
+	if err := c.scheduleNextRecheck(node, now); err != nil {
+		log.Info().Err(err).Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("failed to schedule node recheck")
 	}
 }
 
-// RecheckNodes starts a blocking process where every recheckInterval seconds
-// the crawler checks for all stale nodes that need to be rechecked. For each
-// stale node, the node is added back into the node pool to be re-crawled and
-// updated (or removed).
-func (c *Crawler) RecheckNodes() {
-	ticker := time.NewTicker(time.Duration(c.recheckInterval) * time.Second)
+// getStatus queries a node's status via its RPC address while holding a
+// connection slot.
+func (c *Crawler) getStatus(ctx context.Context, nodeRPCAddr string) (*ctypes.ResultStatus, error) {
+	c.conns.acquire()
+	defer c.conns.release()
 
-	for range ticker.C {
-		now := time.Now().UTC()
-		log.Info().Str("time", now.Format(time.RFC3339)).Msg("rechecking nodes...")
+	return newRPCClient(ctx, c.dialer, nodeRPCAddr).Status()
+}
 
-		nodes, err := c.GetStaleNodes(now)
-		if err != nil {
-			log.Info().Err(err).Msg("failed to get all stale nodes")
-			continue
-		}
+// getNetInfo queries a node's net info along with the round-trip time of the
+// call.
+func getNetInfo(client *rpcclient.HTTP) (*ctypes.ResultNetInfo, time.Duration, error) {
+	start := time.Now()
+	netInfo, err := client.NetInfo()
+
+	return netInfo, time.Since(start), err
+}
+
+// p2pExchange contains the outcome of a P2P exchange with a node.
+type p2pExchange struct {
+	nodeInfo   p2p.DefaultNodeInfo
+	addrs      []*p2p.NetAddress
+	latency    time.Duration
+	connectRTT time.Duration
+}
+
+// exchangeP2P performs a P2P handshake with a node while holding a connection
+// slot. If the node supports the PEX reactor, its known peer addresses are also
+// requested. Failing to get peer addresses is logged but does not result in an
+// error as the node was still reachable. The latency of the handshake and the
+// round-trip time of its TCP connect are recorded regardless of whether it
+// succeeds.
+func (c *Crawler) exchangeP2P(ctx context.Context, address string) (p2pExchange, error) {
+	c.conns.acquire()
+	defer c.conns.release()
+
+	start := time.Now()
+	sc, nodeInfo, connectRTT, err := dialPeer(ctx, c.dialer, address, c.nodeKey, handshakeTimeout)
+
+	exchange := p2pExchange{nodeInfo: nodeInfo, latency: time.Since(start), connectRTT: connectRTT}
+	if err != nil {
+		return exchange, err
+	}
+
+	if !SupportsPEX(nodeInfo) {
+		_ = sc.Close()
+		return exchange, nil
+	}
+
+	exchange.addrs, err = RequestAddrs(ctx, sc, pexTimeout)
+	if err != nil {
+		log.Info().Err(err).Str("p2p_address", address).Msg("failed to request PEX addresses")
+	}
+
+	return exchange, nil
+}
+
+// recordProbe persists a probe result of a node, prunes probe results that
+// fall outside of the history window and updates the node's availability
+// statistics from its remaining history. The node's first seen time is
+// carried over from its persisted record if one exists.
+func (c *Crawler) recordProbe(n *Node, now time.Time, r ProbeResult) error {
+	if prev, err := GetNode(c.db, n.ID); err == nil && n.FirstSeen == "" {
+		n.FirstSeen = prev.FirstSeen
+	}
+
+	bz, err := r.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := c.db.Set(ProbeKey(n.ID, now), bz); err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-probeHistoryWindow)
+	if err := c.pruneProbeHistory(n.ID, cutoff); err != nil {
+		return err
+	}
+
+	history, err := GetProbeHistory(c.db, n.ID, cutoff)
+	if err != nil {
+		return err
+	}
+
+	updateProbeStats(n, history, now)
+	return nil
+}
+
+// deleteReplacedNode removes the expected node of a P2P address which
+// authenticated as another node, e.g. after the node's key was rotated, as the
+// expected node is no longer reachable at its address. The node is only removed
+// if it is still persisted under that address.
+func (c *Crawler) deleteReplacedNode(expectedID, address string) error {
+	prev, err := GetNode(c.db, expectedID)
+	if err == ErrNodeNotFound || (err == nil && prev.P2PAddress() != address) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return c.deleteNode(prev)
+}
+
+// recordFailedProbe records a failed probe result for the node persisted under
+// the given node's P2P address, if any, and applies the retention policy to it.
+// The node is marked offline after offlineFailures consecutive failed probes or
+// when it has not been successfully probed for offlineAfter. An offline node is
+// deleted once it has not been successfully probed for purgeAfter. If it is
+// unknown when the node was last seen, only its failed probes are counted.
+func (c *Crawler) recordFailedProbe(n Node, now time.Time, r ProbeResult) error {
+	prev, err := GetNode(c.db, n.P2PAddress())
+	if err == ErrNodeNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// nodes persisted before probes were recorded, e.g. nodes rekeyed by
+	// MigrateNodeKeys, fall back to their previous sync or first seen time
+	lastSync := prev.LastSync
+
+	prev.LastSync = n.LastSync
+	if err := c.recordProbe(&prev, now, r); err != nil {
+		return err
+	}
 
-		for _, node := range nodes {
-			nodeP2PAddr := fmt.Sprintf("%s:%s", node.Address, node.P2PPort)
-			nodeRPCAddr := fmt.Sprintf("http://%s:%s", node.Address, node.RPCPort)
+	lastSeen, seen := parseFirstTime(prev.LastSeen, lastSync, prev.FirstSeen)
+	unseenFor := now.Sub(lastSeen)
 
-			log.Debug().Str("p2p_address", nodeP2PAddr).Str("rpc_address", nodeRPCAddr).Msg("adding node to node pool")
-			c.pool.AddNode(nodeRPCAddr)
+	if seen && unseenFor >= c.purgeAfter {
+		log.Info().Str("p2p_address", n.P2PAddress()).Dur("unseen_for", unseenFor).Msg("purging offline node...")
+		c.runs.update(func(r *CrawlRun) { r.Purged++ })
+		return c.deleteNode(prev)
+	}
+
+	if prev.ConsecutiveFailures >= c.offlineFailures || (seen && unseenFor >= c.offlineAfter) {
+		if prev.Status != NodeStatusOffline {
+			log.Info().Str("p2p_address", n.P2PAddress()).Uint("failures", prev.ConsecutiveFailures).Msg("marking node offline")
 		}
+
+		prev.Status = NodeStatusOffline
 	}
+
+	if err := c.SaveNode(prev); err != nil {
+		return err
+	}
+
+	return c.scheduleNextRecheck(prev, now)
 }
 
-// GetStaleNodes returns all persisted nodes from that database that have a
-// LastSync time that is older than the provided time.
-//
-// NOTE: We currently query for all nodes and for each node we check the LastSync
-// value. This should ideally be improved in case the node set size is
-// substantially large. It may require thinking the persistence interface.
-func (c *Crawler) GetStaleNodes(t time.Time) ([]Node, error) {
-	nodes := []Node{}
+// parseFirstTime returns the first of the given RFC3339 timestamps which can be
+// parsed. False is returned if none of them can be parsed, e.g. if all are
+// empty.
+func parseFirstTime(timestamps ...string) (time.Time, bool) {
+	for _, ts := range timestamps {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t, true
+		}
+	}
 
-	var err error
-	c.db.IteratePrefix(NodeKeyPrefix, func(_, v []byte) bool {
-		node := new(Node)
+	return time.Time{}, false
+}
 
-		err = node.Unmarshal(v)
-		if err != nil {
+// pruneProbeHistory removes all probe results of a node made before the given
+// time.
+func (c *Crawler) pruneProbeHistory(id string, before time.Time) error {
+	end := ProbeKey(id, before)
+	keys := [][]byte{}
+
+	c.db.IteratePrefix(ProbeNodeKeyPrefix(id), func(k, _ []byte) bool {
+		if string(k) >= string(end) {
 			return true
 		}
 
-		lastSync, _ := time.Parse(time.RFC3339, node.LastSync)
-		if lastSync.Before(t) {
-			nodes = append(nodes, *node)
+		keys = append(keys, append([]byte{}, k...))
+		return false
+	})
+
+	for _, k := range keys {
+		if err := c.db.Delete(k); err != nil {
+			return err
 		}
+	}
 
+	return nil
+}
+
+// deleteProbeHistory removes all probe results of a node.
+func (c *Crawler) deleteProbeHistory(id string) error {
+	keys := [][]byte{}
+
+	c.db.IteratePrefix(ProbeNodeKeyPrefix(id), func(k, _ []byte) bool {
+		keys = append(keys, append([]byte{}, k...))
 		return false
 	})
 
-	if err != nil {
-		return nil, err
+	for _, k := range keys {
+		if err := c.db.Delete(k); err != nil {
+			return err
+		}
 	}
 
-	return nodes, nil
+	return nil
 }
 
-// SaveNode persists a node to the database by it's addressable key. An error is
-// returned if it cannot be marshaled or the database operation fails.
+// RecheckNodes starts a blocking process where every recheckPollInterval the
+// crawler pulls all nodes that are due for a recheck from the persisted
+// schedule. For each due node, the node is added back into the node pool to be
+// re-crawled and updated (or marked offline). Its entry is pushed back by
+// recheckInterval so the node is not queued again before the crawl reschedules
+// it using its adaptive recheck interval. RecheckNodes returns once the context
+// is canceled.
+func (c *Crawler) RecheckNodes(ctx context.Context) {
+	if err := c.backfillIndexes(time.Now().UTC()); err != nil {
+		log.Info().Err(err).Msg("failed to backfill node indexes")
+	}
+
+	pollInterval := time.Duration(c.recheckInterval) * time.Second
+	if pollInterval > recheckPollInterval {
+		pollInterval = recheckPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+		due := c.GetDueRechecks(now)
+		if len(due) == 0 {
+			continue
+		}
+
+		log.Info().Str("time", now.Format(time.RFC3339)).Int("due", len(due)).Msg("rechecking nodes...")
+
+		for id, nodeAddr := range due {
+			node := Node{ID: id}
+			_, node.Address, _, _, node.P2PPort = parseNodeAddr(nodeAddr)
+
+			retry := now.Add(time.Duration(c.recheckInterval) * time.Second)
+			if err := c.ScheduleRecheck(node, retry); err != nil {
+				log.Info().Err(err).Str("p2p_address", nodeAddr).Msg("failed to reschedule node recheck")
+			}
+
+			log.Debug().Str("p2p_address", nodeAddr).Msg("adding node to node pool")
+			c.pool.AddNode(nodeAddr)
+		}
+	}
+}
+
+// SaveNode persists a node to the database by its node ID along with an index
+// entry from its P2P address to its ID. If the node was previously persisted
+// under a different address, e.g. after an IP change, the stale index entry is
+// removed. The node is added to or removed from the healthy node index
+// depending on its status and latest probe. An error is returned if it cannot
+// be marshaled or the database operation fails.
 func (c *Crawler) SaveNode(n Node) error {
 	bz, err := n.Marshal()
 	if err != nil {
 		return err
 	}
 
+	if prev, err := GetNode(c.db, n.ID); err == nil && prev.P2PAddress() != n.P2PAddress() {
+		if err := c.deleteAddressIndex(prev); err != nil {
+			return err
+		}
+	}
+
 	if err := c.db.Set(n.Key(), bz); err != nil {
 		return err
 	}
 
-	return nil
+	if err := c.db.Set(n.AddressKey(), []byte(n.ID)); err != nil {
+		return err
+	}
+
+	return c.indexHealthy(n)
 }
 
-// DeleteNodeIfExist removes a node by it's addressable key from the database
-// if it exists. An error is returned if it exists and cannot be deleted.
+// DeleteNodeIfExist removes a node by its P2P address from the database if it
+// exists. The node is resolved through the address index, so the node ID does
+// not need to be known. The node itself, along with its scheduled recheck and
+// peer graph edges, is only removed if it is still persisted under that
+// address. An error is returned if it exists and cannot be deleted.
 func (c *Crawler) DeleteNodeIfExist(n Node) error {
-	key := n.Key()
-	if c.db.Has(key) {
-		return c.db.Delete(key)
+	addrKey := n.AddressKey()
+	if !c.db.Has(addrKey) {
+		return nil
 	}
 
-	return nil
+	id, err := c.db.Get(addrKey)
+	if err != nil {
+		return err
+	}
+
+	if node, err := GetNode(c.db, string(id)); err == nil && node.P2PAddress() == n.P2PAddress() {
+		return c.deleteNode(node)
+	}
+
+	return c.db.Delete(addrKey)
+}
+
+// deleteNode removes a persisted node along with its scheduled recheck, healthy
+// index entry, probe history and peer graph edges. Its address index entry is
+// only removed if it still refers to the node, as another node may have since
+// been found at its address.
+func (c *Crawler) deleteNode(n Node) error {
+	if err := c.db.Delete(n.Key()); err != nil {
+		return err
+	}
+
+	if err := c.UnscheduleRecheck(n.ID); err != nil {
+		return err
+	}
+
+	if err := c.db.Delete(HealthyKey(n.ID)); err != nil {
+		return err
+	}
+
+	if err := c.deleteProbeHistory(n.ID); err != nil {
+		return err
+	}
+
+	if err := c.deletePeers(n.ID); err != nil {
+		return err
+	}
+
+	return c.deleteAddressIndex(n)
+}
+
+// indexHealthy adds a node to the healthy node index if it is online and its
+// latest probe succeeded, otherwise it removes the node from the index.
+func (c *Crawler) indexHealthy(n Node) error {
+	if n.Status == NodeStatusOnline && n.ConsecutiveFailures == 0 {
+		return c.db.Set(HealthyKey(n.ID), []byte(n.P2PAddress()))
+	}
+
+	return c.db.Delete(HealthyKey(n.ID))
+}
+
+// deleteAddressIndex removes the address index entry of a node if it still
+// refers to that node.
+func (c *Crawler) deleteAddressIndex(n Node) error {
+	addrKey := n.AddressKey()
+
+	id, err := c.db.Get(addrKey)
+	if err != nil || string(id) != n.ID {
+		return nil
+	}
+
+	return c.db.Delete(addrKey)
 }
 
 // GetGeolocation returns a Location object containing geolocation information
@@ -0,0 +1,244 @@
+package crawl_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNodeAddr(t *testing.T) {
+	id, host, rpcScheme, rpcPort, p2pPort := crawl.ParseNodeAddr("5A8A6061C8A2E2E02D497060D5325B6588051CC6@1.2.3.4:26656")
+	require.Equal(t, "5a8a6061c8a2e2e02d497060d5325b6588051cc6", id)
+	require.Equal(t, "1.2.3.4", host)
+	require.Empty(t, rpcScheme)
+	require.Empty(t, rpcPort)
+	require.Equal(t, "26656", p2pPort)
+
+	id, host, rpcScheme, rpcPort, p2pPort = crawl.ParseNodeAddr("http://1.2.3.4:26657")
+	require.Empty(t, id)
+	require.Equal(t, "1.2.3.4", host)
+	require.Equal(t, "http", rpcScheme)
+	require.Equal(t, "26657", rpcPort)
+	require.Empty(t, p2pPort)
+
+	// the scheme of TLS endpoints is kept
+	_, host, rpcScheme, rpcPort, _ = crawl.ParseNodeAddr("https://rpc.cosmos.network:443")
+	require.Equal(t, "rpc.cosmos.network", host)
+	require.Equal(t, "https", rpcScheme)
+	require.Equal(t, "443", rpcPort)
+}
+
+func TestNewRPCClient_ResponseLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bytes.Repeat([]byte(" "), 1<<20))
+	}))
+	defer srv.Close()
+
+	_, err := crawl.NewRPCClient(context.Background(), &net.Dialer{}, srv.URL).Status()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), crawl.ErrResponseTooLarge.Error())
+}
+
+func TestCrawler_DeleteReplacedNode(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{RecheckInterval: 3600}, bdb)
+	now := time.Now().UTC()
+
+	old := crawl.Node{ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6", Address: "1.2.3.4", P2PPort: "26656", Status: crawl.NodeStatusOnline}
+	moved := crawl.Node{ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", Address: "5.6.7.8", P2PPort: "26656", Status: crawl.NodeStatusOnline}
+
+	for _, n := range []crawl.Node{old, moved} {
+		require.NoError(t, c.SaveNode(n))
+		require.NoError(t, c.ScheduleRecheck(n, now))
+	}
+
+	// the node at the address authenticated with another ID
+	require.NoError(t, c.DeleteReplacedNode(old.ID, old.P2PAddress()))
+
+	_, err = crawl.GetNode(bdb, old.ID)
+	require.Equal(t, crawl.ErrNodeNotFound, err)
+	require.False(t, bdb.Has(old.AddressKey()))
+
+	// a node which is persisted under another address is kept
+	require.NoError(t, c.DeleteReplacedNode(moved.ID, old.P2PAddress()))
+
+	_, err = crawl.GetNode(bdb, moved.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{moved.ID: moved.ID + "@5.6.7.8:26656"}, c.GetDueRechecks(now))
+}
+
+func TestCrawler_Reseed(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{ReseedSize: 10}, bdb)
+
+	healthy := crawl.Node{ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6", Address: "1.2.3.4", P2PPort: "26656", Status: crawl.NodeStatusOnline}
+	failing := crawl.Node{ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", Address: "5.6.7.8", P2PPort: "26656", Status: crawl.NodeStatusOnline, ConsecutiveFailures: 1}
+	offline := crawl.Node{ID: "0d6f4c3e2b1a09f8e7d6c5b4a3928170f6e5d4c3", Address: "9.8.7.6", P2PPort: "26656", Status: crawl.NodeStatusOffline}
+	removed := crawl.Node{ID: "9c8b7a6f5e4d3c2b1a0f9e8d7c6b5a4f3e2d1c0b", Address: "4.3.2.1", P2PPort: "26656", Status: crawl.NodeStatusOnline}
+
+	for _, n := range []crawl.Node{healthy, failing, offline, removed} {
+		require.NoError(t, c.SaveNode(n))
+	}
+
+	// a healthy node which fails its next probe is no longer reseeded
+	failing.ConsecutiveFailures = 0
+	require.NoError(t, c.SaveNode(failing))
+	failing.ConsecutiveFailures = 1
+	require.NoError(t, c.SaveNode(failing))
+
+	require.NoError(t, c.DeleteNodeIfExist(removed))
+
+	c.Reseed()
+
+	pending := []string{}
+	bdb.IteratePrefix(crawl.PoolPendingKeyPrefix, func(k, _ []byte) bool {
+		pending = append(pending, string(k[len(crawl.PoolPendingKeyPrefix):]))
+		return false
+	})
+
+	require.Equal(t, []string{healthy.ID + "@" + healthy.P2PAddress()}, pending)
+}
+
+func TestCrawler_RecordFailedProbe(t *testing.T) {
+	testCases := []struct {
+		name       string
+		failures   int
+		unseenFor  time.Duration
+		legacy     bool
+		expStatus  string
+		expRemoved bool
+	}{
+		{"below failure threshold", 2, time.Minute, false, crawl.NodeStatusOnline, false},
+		{"failure threshold reached", 3, time.Minute, false, crawl.NodeStatusOffline, false},
+		{"offline timeout exceeded", 1, 2 * time.Hour, false, crawl.NodeStatusOffline, false},
+		{"purge timeout exceeded", 1, 48 * time.Hour, false, "", true},
+		{"last seen unknown", 1, 0, true, crawl.NodeStatusOnline, false},
+		{"legacy last sync purge timeout exceeded", 1, 48 * time.Hour, true, "", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			bdb, err := db.NewBadgerMemDB()
+			require.NoError(t, err)
+
+			cfg := config.Config{MaxConns: 1, OfflineFailures: 3, OfflineAfter: 3600, PurgeAfter: 86400}
+			c := crawl.NewCrawler(cfg, config.NetworkConfig{RecheckInterval: 3600}, bdb)
+			now := time.Now().UTC().Truncate(time.Second)
+
+			node := crawl.Node{
+				ID:      "5a8a6061c8a2e2e02d497060d5325b6588051cc6",
+				Address: "1.2.3.4",
+				P2PPort: "26656",
+				Status:  crawl.NodeStatusOnline,
+			}
+
+			// legacy nodes, e.g. nodes rekeyed by MigrateNodeKeys, only carry
+			// the time of their last sync, if any
+			switch {
+			case !tc.legacy:
+				node.LastSeen = now.Add(-tc.unseenFor).Format(time.RFC3339)
+
+			case tc.unseenFor > 0:
+				node.LastSync = now.Add(-tc.unseenFor).Format(time.RFC3339)
+			}
+
+			require.NoError(t, c.SaveNode(node))
+			require.NoError(t, c.ScheduleRecheck(node, now))
+
+			for i := tc.failures; i > 0; i-- {
+				ts := now.Add(-time.Duration(i-1) * time.Second)
+				require.NoError(t, c.RecordFailedProbe(node, ts, crawl.NewProbeResult(ts, 0, false)))
+			}
+
+			if tc.expRemoved {
+				_, err := crawl.GetNode(bdb, node.ID)
+				require.Equal(t, crawl.ErrNodeNotFound, err)
+				require.False(t, bdb.Has(node.AddressKey()))
+				require.False(t, bdb.Has(crawl.ScheduleRefKey(node.ID)))
+				require.False(t, bdb.Has(crawl.HealthyKey(node.ID)))
+				require.Empty(t, c.GetDueRechecks(now.Add(365*24*time.Hour)))
+
+				history, err := crawl.GetProbeHistory(bdb, node.ID, now.Add(-time.Hour))
+				require.NoError(t, err)
+				require.Empty(t, history)
+				return
+			}
+
+			got, err := crawl.GetNode(bdb, node.P2PAddress())
+			require.NoError(t, err)
+			require.Equal(t, tc.expStatus, got.Status)
+			require.Equal(t, uint(tc.failures), got.ConsecutiveFailures)
+			require.Equal(t, node.LastSeen, got.LastSeen)
+			require.False(t, bdb.Has(crawl.HealthyKey(node.ID)))
+			require.Len(t, c.GetDueRechecks(now.Add(365*24*time.Hour)), 1)
+
+			history, err := crawl.GetProbeHistory(bdb, node.ID, now.Add(-time.Hour))
+			require.NoError(t, err)
+			require.Len(t, history, tc.failures)
+		})
+	}
+
+	// failed probes of unknown nodes are not recorded
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{}, bdb)
+	node := crawl.Node{ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6", Address: "1.2.3.4", P2PPort: "26656"}
+
+	require.NoError(t, c.RecordFailedProbe(node, time.Now().UTC(), crawl.NewProbeResult(time.Now(), 0, false)))
+
+	_, err = crawl.GetNode(bdb, node.ID)
+	require.Equal(t, crawl.ErrNodeNotFound, err)
+}
+
+func TestCrawler_CrawlNode_HTTPSSeed(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	// record the first byte sent by the crawler, which is the record type of a
+	// TLS handshake if the seed's scheme is kept
+	firstByte := make(chan byte, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		b := make([]byte, 1)
+		if _, err := c.Read(b); err == nil {
+			firstByte <- b[0]
+		}
+	}()
+
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{}, bdb)
+	c.CrawlNode(context.Background(), "https://"+l.Addr().String())
+
+	select {
+	case b := <-firstByte:
+		require.Equal(t, byte(0x16), b)
+
+	default:
+		t.Fatal("status was not queried")
+	}
+}
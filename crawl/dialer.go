@@ -0,0 +1,166 @@
+package crawl
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"golang.org/x/net/proxy"
+)
+
+type (
+	// Dialer defines the interface by which all outbound connections of the
+	// crawler are made, i.e. P2P handshakes, RPC queries, service probes and
+	// geolocation queries.
+	Dialer interface {
+		DialContext(ctx context.Context, network, address string) (net.Conn, error)
+	}
+
+	// httpConnectDialer implements a Dialer which tunnels connections through
+	// an HTTP proxy using the CONNECT method.
+	httpConnectDialer struct {
+		proxyAddr string
+		user      *url.Userinfo
+		forward   Dialer
+	}
+
+	// bufferedConn implements a net.Conn whose reads are served from a buffered
+	// reader first, which may hold data the proxy sent after its response.
+	bufferedConn struct {
+		net.Conn
+		r *bufio.Reader
+	}
+)
+
+// NewDialer returns a Dialer which connects through the given proxy, which is
+// parsed by config.ParseProxy. SOCKS5 proxies are used via the socks5 and
+// socks5h schemes and HTTP proxies, which must support the CONNECT method, via
+// the http scheme. Credentials are taken from the proxy's user info. If the
+// proxy is empty or direct, the Dialer connects directly. An error is returned
+// if the proxy is invalid.
+func NewDialer(proxyURL string) (Dialer, error) {
+	u, err := config.ParseProxy(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := &net.Dialer{}
+	if u == nil {
+		return direct, nil
+	}
+
+	if u.Scheme == "http" {
+		return httpConnectDialer{proxyAddr: u.Host, user: u.User, forward: direct}, nil
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+
+	d, err := proxy.SOCKS5("tcp", u.Host, auth, direct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
+	}
+
+	return d.(proxy.ContextDialer), nil
+}
+
+// DialContext connects to the proxy and requests a tunnel to the address. The
+// tunnel is returned once the proxy accepts the request. The CONNECT exchange is
+// aborted if the context is canceled or its deadline is exceeded.
+func (d httpConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	c, err := d.forward.DialContext(ctx, network, d.proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial proxy: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	// unblock the exchange by closing the connection upon cancellation
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+
+		case <-stop:
+		}
+	}()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+
+	if d.user != nil {
+		password, _ := d.user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	r := bufio.NewReader(c)
+
+	resp, err := d.connect(c, r, req)
+	if err != nil {
+		c.Close()
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.Close()
+		return nil, fmt.Errorf("proxy refused to connect to %s: %s", address, resp.Status)
+	}
+
+	if err := c.SetDeadline(time.Time{}); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	if r.Buffered() > 0 {
+		return bufferedConn{Conn: c, r: r}, nil
+	}
+
+	return c, nil
+}
+
+// connect writes the CONNECT request to the proxy and reads its response.
+func (d httpConnectDialer) connect(c net.Conn, r *bufio.Reader, req *http.Request) (*http.Response, error) {
+	if err := req.Write(c); err != nil {
+		return nil, fmt.Errorf("failed to write proxy request: %w", err)
+	}
+
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (c bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,222 @@
+package crawl_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+)
+
+// testProxy implements a proxy which records the addresses it tunnels to.
+type testProxy struct {
+	net.Listener
+
+	mtx     sync.Mutex
+	targets []string
+}
+
+func newTestProxy(t *testing.T, handshake func(c net.Conn, r *bufio.Reader) (string, bool)) *testProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &testProxy{Listener: l}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer c.Close()
+
+				r := bufio.NewReader(c)
+
+				target, ok := handshake(c, r)
+				if !ok {
+					return
+				}
+
+				p.mtx.Lock()
+				p.targets = append(p.targets, target)
+				p.mtx.Unlock()
+
+				tc, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+
+				defer tc.Close()
+
+				go func() { _, _ = io.Copy(tc, r) }()
+				_, _ = io.Copy(c, tc)
+			}()
+		}
+	}()
+
+	return p
+}
+
+func (p *testProxy) Targets() []string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return append([]string{}, p.targets...)
+}
+
+// httpConnectHandshake accepts CONNECT requests authenticated as user:pass.
+func httpConnectHandshake(c net.Conn, r *bufio.Reader) (string, bool) {
+	req, err := http.ReadRequest(r)
+	if err != nil || req.Method != http.MethodConnect {
+		return "", false
+	}
+
+	if user, pass, ok := proxyBasicAuth(req); !ok || user != "user" || pass != "pass" {
+		_, _ = fmt.Fprint(c, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return "", false
+	}
+
+	_, _ = fmt.Fprint(c, "HTTP/1.1 200 Connection established\r\n\r\n")
+	return req.Host, true
+}
+
+func proxyBasicAuth(req *http.Request) (string, string, bool) {
+	r := &http.Request{Header: http.Header{"Authorization": req.Header["Proxy-Authorization"]}}
+	return r.BasicAuth()
+}
+
+// socks5Handshake accepts unauthenticated SOCKS5 CONNECT requests to IPv4
+// addresses and domain names.
+func socks5Handshake(c net.Conn, r *bufio.Reader) (string, bool) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil || header[0] != 0x05 {
+		return "", false
+	}
+
+	if _, err := io.ReadFull(r, make([]byte, header[1])); err != nil {
+		return "", false
+	}
+
+	// no authentication required
+	if _, err := c.Write([]byte{0x05, 0x00}); err != nil {
+		return "", false
+	}
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(r, req); err != nil || req[1] != 0x01 {
+		return "", false
+	}
+
+	var host string
+
+	switch req[3] {
+	case 0x01:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", false
+		}
+
+		host = net.IP(ip).String()
+
+	case 0x03:
+		n, err := r.ReadByte()
+		if err != nil {
+			return "", false
+		}
+
+		name := make([]byte, n)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return "", false
+		}
+
+		host = string(name)
+
+	default:
+		return "", false
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", false
+	}
+
+	if _, err := c.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+		return "", false
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), true
+}
+
+func newTestRPCServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<a href="//localhost/status?">status</a>`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestNewDialer(t *testing.T) {
+	d, err := crawl.NewDialer("")
+	require.NoError(t, err)
+	require.IsType(t, &net.Dialer{}, d)
+
+	d, err = crawl.NewDialer("direct")
+	require.NoError(t, err)
+	require.IsType(t, &net.Dialer{}, d)
+
+	for _, invalid := range []string{"ftp://127.0.0.1:21", "127.0.0.1:1080", "socks5://127.0.0.1"} {
+		_, err := crawl.NewDialer(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestNewDialer_HTTPConnect(t *testing.T) {
+	srv := newTestRPCServer(t)
+	defer srv.Close()
+
+	p := newTestProxy(t, httpConnectHandshake)
+	defer p.Close()
+
+	d, err := crawl.NewDialer(fmt.Sprintf("http://user:pass@%s", p.Addr()))
+	require.NoError(t, err)
+
+	exposure, err := crawl.AuditRPC(context.Background(), d, srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, []string{"status"}, exposure.Routes)
+	require.Contains(t, p.Targets(), srv.Listener.Addr().String())
+
+	// requests without valid credentials are refused by the proxy
+	d, err = crawl.NewDialer(fmt.Sprintf("http://user:invalid@%s", p.Addr()))
+	require.NoError(t, err)
+
+	_, err = d.DialContext(context.Background(), "tcp", srv.Listener.Addr().String())
+	require.Error(t, err)
+}
+
+func TestNewDialer_SOCKS5(t *testing.T) {
+	srv := newTestRPCServer(t)
+	defer srv.Close()
+
+	p := newTestProxy(t, socks5Handshake)
+	defer p.Close()
+
+	d, err := crawl.NewDialer(fmt.Sprintf("socks5://%s", p.Addr()))
+	require.NoError(t, err)
+
+	exposure, err := crawl.AuditRPC(context.Background(), d, srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, []string{"status"}, exposure.Routes)
+	require.Contains(t, p.Targets(), srv.Listener.Addr().String())
+}
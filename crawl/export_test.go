@@ -0,0 +1,36 @@
+package crawl
+
+import "time"
+
+// Unexported functionality exposed to the external test package.
+var (
+	ParseNodeAddr       = parseNodeAddr
+	NextRecheckInterval = nextRecheckInterval
+	UpdateProbeStats    = updateProbeStats
+	NewRPCClient        = newRPCClient
+	ErrResponseTooLarge = errResponseTooLarge
+)
+
+func (c *Crawler) DeleteReplacedNode(expectedID, address string) error {
+	return c.deleteReplacedNode(expectedID, address)
+}
+
+func (c *Crawler) Reseed() {
+	c.reseed()
+}
+
+func (c *Crawler) StartCrawlRun(now time.Time) {
+	c.startCrawlRun(now)
+}
+
+func (c *Crawler) AttemptCrawlRun() {
+	c.attemptCrawlRun()
+}
+
+func (c *Crawler) FinishCrawlRun(now time.Time) {
+	c.finishCrawlRun(now)
+}
+
+func (c *Crawler) RecordFailedProbe(n Node, now time.Time, r ProbeResult) error {
+	return c.recordFailedProbe(n, now, r)
+}
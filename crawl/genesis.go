@@ -0,0 +1,84 @@
+package crawl
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	amino "github.com/tendermint/go-amino"
+	cryptoamino "github.com/tendermint/tendermint/crypto/encoding/amino"
+	"github.com/tendermint/tendermint/types"
+)
+
+// genesisTimeout defines the timeout of genesis queries which may take longer
+// than other RPC queries as genesis documents can be large.
+const genesisTimeout = 30 * time.Second
+
+// maxGenesisSize defines the maximum number of bytes read from a genesis
+// response. Larger genesis documents are not hashed.
+const maxGenesisSize = 32 << 20
+
+// GenesisKeyPrefix defines the persistence prefix key of cached genesis hashes.
+var GenesisKeyPrefix = []byte("genesis/")
+
+var genesisCdc = amino.NewCodec()
+
+func init() {
+	cryptoamino.RegisterAmino(genesisCdc)
+}
+
+// GenesisKey constructs the DB key for genesis hash caching by network.
+func GenesisKey(network string) []byte {
+	return append(GenesisKeyPrefix, []byte(network)...)
+}
+
+// GenesisHash returns the fingerprint of a genesis document which is the
+// SHA256 hash of its JSON encoding. Nodes of forks or unrelated networks that
+// share a chain ID have different genesis hashes.
+func GenesisHash(genDoc *types.GenesisDoc) (string, error) {
+	bz, err := genesisCdc.MarshalJSON(genDoc)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%X", sha256.Sum256(bz)), nil
+}
+
+// GetGenesisHash returns the genesis hash of a network. It will first check to
+// see if the hash already exists in the database and return it if so.
+// Otherwise, the genesis is queried via the RPC address of a node of the
+// network and its hash is persisted, so the genesis is queried at most once per
+// network. Queries are serialized so concurrent crawls of a network's nodes do
+// not fetch its genesis more than once. An error is returned if the genesis
+// cannot be queried for, exceeds maxGenesisSize or cannot be hashed.
+func (c *Crawler) GetGenesisHash(ctx context.Context, network, nodeRPCAddr string) (string, error) {
+	c.genesisMtx.Lock()
+	defer c.genesisMtx.Unlock()
+
+	genKey := GenesisKey(network)
+
+	// return the genesis hash if it exists in the database
+	if c.db.Has(genKey) {
+		bz, err := c.db.Get(genKey)
+		if err != nil {
+			return "", err
+		}
+
+		return string(bz), nil
+	}
+
+	// query for the genesis and persist its hash
+	res, err := newRPCClientWithLimits(ctx, c.dialer, nodeRPCAddr, genesisTimeout, maxGenesisSize).Genesis()
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := GenesisHash(res.Genesis)
+	if err != nil {
+		return "", err
+	}
+
+	err = c.db.Set(genKey, []byte(hash))
+	return hash, err
+}
@@ -0,0 +1,105 @@
+package crawl_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestGenesisHash(t *testing.T) {
+	genDoc := &types.GenesisDoc{
+		GenesisTime: time.Date(2019, 12, 11, 16, 11, 34, 0, time.UTC),
+		ChainID:     "chain-0",
+		Validators: []types.GenesisValidator{
+			{PubKey: ed25519.GenPrivKey().PubKey(), Power: 10, Name: "val-0"},
+		},
+	}
+
+	hash, err := crawl.GenesisHash(genDoc)
+	require.NoError(t, err)
+	require.Len(t, hash, 64)
+
+	other, err := crawl.GenesisHash(genDoc)
+	require.NoError(t, err)
+	require.Equal(t, hash, other)
+
+	// a fork with the same chain ID has a different genesis hash
+	fork := *genDoc
+	fork.GenesisTime = fork.GenesisTime.Add(time.Hour)
+
+	other, err = crawl.GenesisHash(&fork)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other)
+}
+
+func TestCrawler_GetGenesisHash(t *testing.T) {
+	var queries int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"genesis":{"genesis_time":"2019-12-11T16:11:34Z","chain_id":"chain-0"}}}`, req.ID)
+	}))
+	defer srv.Close()
+
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{}, bdb)
+
+	hash, err := c.GetGenesisHash(context.Background(), "chain-0", srv.URL)
+	require.NoError(t, err)
+	require.NotEmpty(t, hash)
+
+	// the genesis hash is cached per network
+	cached, err := c.GetGenesisHash(context.Background(), "chain-0", srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, hash, cached)
+	require.Equal(t, int32(1), atomic.LoadInt32(&queries))
+
+	_, err = c.GetGenesisHash(context.Background(), "chain-1", srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&queries))
+}
+
+func TestCrawler_GetGenesisHash_TooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"genesis":{"chain_id":"chain-0","app_state":"%s"}}}`, req.ID, strings.Repeat("a", 33<<20))
+	}))
+	defer srv.Close()
+
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{}, bdb)
+
+	_, err = c.GetGenesisHash(context.Background(), "chain-0", srv.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), crawl.ErrResponseTooLarge.Error())
+
+	_, err = bdb.Get(crawl.GenesisKey("chain-0"))
+	require.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package crawl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/harwoeck/ipstack"
+)
+
+const ipstackTimeout = 5 * time.Second
+
+// ipstackClient implements a client of the ipstack geolocation API which
+// connects via the crawler's dialer. As with ipstack's own client, the API is
+// queried over plain HTTP as HTTPS is not offered in its free tier.
+type ipstackClient struct {
+	accessKey string
+	client    *http.Client
+}
+
+func newIPStackClient(accessKey string, dialer Dialer) *ipstackClient {
+	return &ipstackClient{
+		accessKey: accessKey,
+		client:    newHTTPClient(context.Background(), dialer, ipstackTimeout),
+	}
+}
+
+// Check queries the geolocation of an IP address. An error is returned if the
+// query fails or its response cannot be decoded.
+func (c *ipstackClient) Check(ip string) (*ipstack.Response, error) {
+	query := url.Values{}
+	query.Set("access_key", c.accessKey)
+	query.Set("hostname", "1")
+	query.Set("language", "en")
+	query.Set("output", "json")
+
+	resp, err := c.client.Get(fmt.Sprintf("http://api.ipstack.com/%s?%s", url.PathEscape(ip), query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	r := new(ipstack.Response)
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, fmt.Errorf("failed to decode geolocation: %w", err)
+	}
+
+	return r, nil
+}
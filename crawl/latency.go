@@ -0,0 +1,216 @@
+package crawl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// Probe stages at which a crawl of a node may fail.
+const (
+	ProbeStageP2P     = "p2p"
+	ProbeStageStatus  = "status"
+	ProbeStageNetInfo = "net_info"
+)
+
+// Error classes of failed probe stages.
+const (
+	ErrorClassTimeout         = "timeout"
+	ErrorClassCanceled        = "canceled"
+	ErrorClassDNS             = "dns"
+	ErrorClassConnRefused     = "connection_refused"
+	ErrorClassConnReset       = "connection_reset"
+	ErrorClassHTTP4xx         = "http_4xx"
+	ErrorClassHTTP5xx         = "http_5xx"
+	ErrorClassRPC             = "rpc_error"
+	ErrorClassInvalidResponse = "invalid_response"
+	ErrorClassOther           = "other"
+)
+
+type (
+	// Percentiles defines the 50th, 90th and 99th percentile of a set of
+	// round-trip times in milliseconds.
+	Percentiles struct {
+		P50 float64 `json:"p50" yaml:"p50"`
+		P90 float64 `json:"p90" yaml:"p90"`
+		P99 float64 `json:"p99" yaml:"p99"`
+	}
+
+	// LatencyStats defines the latency of a node computed from the probes made
+	// within a window. Round-trip times are only computed from the probes in
+	// which the respective step was reached. Errors counts the failed probe
+	// stages by error class.
+	LatencyStats struct {
+		Samples int            `json:"samples" yaml:"samples"`
+		Connect Percentiles    `json:"connect_rtt_ms" yaml:"connect_rtt_ms"`
+		Status  Percentiles    `json:"status_rtt_ms" yaml:"status_rtt_ms"`
+		NetInfo Percentiles    `json:"net_info_rtt_ms" yaml:"net_info_rtt_ms"`
+		Errors  map[string]int `json:"errors" yaml:"errors"`
+	}
+
+	// httpStatusError defines an error for an HTTP response with an error status
+	// which is not a JSON-RPC response, e.g. one returned by a reverse proxy.
+	httpStatusError struct {
+		statusCode int
+	}
+
+	// statusTransport implements an http.RoundTripper which turns responses
+	// with an error status that are not JSON-RPC responses into errors.
+	statusTransport struct {
+		base http.RoundTripper
+	}
+)
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %d %s", e.statusCode, http.StatusText(e.statusCode))
+}
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest && !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		resp.Body.Close()
+		return nil, httpStatusError{statusCode: resp.StatusCode}
+	}
+
+	return resp, nil
+}
+
+// ComputeLatencyStats returns the latency of a node computed from all probes
+// made at or after the given time.
+func ComputeLatencyStats(history []ProbeResult, since time.Time) LatencyStats {
+	stats := LatencyStats{Errors: make(map[string]int)}
+
+	var connect, status, netInfo []float64
+	for _, r := range history {
+		if r.Time().Before(since) {
+			continue
+		}
+
+		stats.Samples++
+
+		if r.ConnectRTT > 0 {
+			connect = append(connect, r.ConnectRTT)
+		}
+		if r.StatusRTT > 0 {
+			status = append(status, r.StatusRTT)
+		}
+		if r.NetInfoRTT > 0 {
+			netInfo = append(netInfo, r.NetInfoRTT)
+		}
+		if r.ErrorClass != "" {
+			stats.Errors[r.ErrorClass]++
+		}
+	}
+
+	stats.Connect = computePercentiles(connect)
+	stats.Status = computePercentiles(status)
+	stats.NetInfo = computePercentiles(netInfo)
+
+	return stats
+}
+
+// computePercentiles returns the nearest-rank percentiles of a set of values.
+// Zero percentiles are returned for an empty set.
+func computePercentiles(values []float64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+
+	sort.Float64s(values)
+
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(values)))) - 1
+		if i < 0 {
+			i = 0
+		}
+
+		return values[i]
+	}
+
+	return Percentiles{P50: rank(50), P90: rank(90), P99: rank(99)}
+}
+
+// ErrorClass returns the class of an error returned by a P2P dial or an RPC
+// call. An empty string is returned for a nil error.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	err = errorCause(err)
+
+	var (
+		netErr    net.Error
+		dnsErr    *net.DNSError
+		statusErr httpStatusError
+		rpcErr    *rpctypes.RPCError
+		jsonErr   *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnRefused
+
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorClassConnReset
+
+	case errors.As(err, &statusErr):
+		if statusErr.statusCode >= http.StatusInternalServerError {
+			return ErrorClassHTTP5xx
+		}
+
+		return ErrorClassHTTP4xx
+
+	case errors.As(err, &rpcErr):
+		return ErrorClassRPC
+
+	case errors.As(err, &jsonErr), errors.As(err, &typeErr):
+		return ErrorClassInvalidResponse
+
+	default:
+		return ErrorClassOther
+	}
+}
+
+// errorCause returns the underlying cause of an error wrapped by the RPC
+// client, which does not support unwrapping.
+func errorCause(err error) error {
+	for {
+		c, ok := err.(interface{ Cause() error })
+		if !ok || c.Cause() == nil {
+			return err
+		}
+
+		err = c.Cause()
+	}
+}
+
+// durationMillis returns a duration in fractional milliseconds.
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
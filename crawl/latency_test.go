@@ -0,0 +1,86 @@
+package crawl_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+func TestComputeLatencyStats(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	history := []crawl.ProbeResult{}
+
+	for i := 1; i <= 10; i++ {
+		r := crawl.NewProbeResult(now.Add(-time.Duration(11-i)*time.Minute), time.Second, true)
+		r.ConnectRTT = float64(i)
+		r.StatusRTT = float64(10 * i)
+		r.NetInfoRTT = float64(100 * i)
+
+		history = append(history, r)
+	}
+
+	// probes outside of the window must not be included
+	old := crawl.NewProbeResult(now.Add(-48*time.Hour), time.Second, true)
+	old.StatusRTT = 1000
+	history = append([]crawl.ProbeResult{old}, history...)
+
+	// failed steps must not be included in the percentiles
+	failed := crawl.NewProbeResult(now, time.Second, false)
+	failed.ConnectRTT = 1
+	failed.SetError(crawl.ProbeStageP2P, context.DeadlineExceeded)
+	history = append(history, failed)
+
+	stats := crawl.ComputeLatencyStats(history, now.Add(-24*time.Hour))
+	require.Equal(t, 11, stats.Samples)
+	require.Equal(t, crawl.Percentiles{P50: 5, P90: 9, P99: 10}, stats.Connect)
+	require.Equal(t, crawl.Percentiles{P50: 50, P90: 90, P99: 100}, stats.Status)
+	require.Equal(t, crawl.Percentiles{P50: 500, P90: 900, P99: 1000}, stats.NetInfo)
+	require.Equal(t, map[string]int{crawl.ErrorClassTimeout: 1}, stats.Errors)
+
+	stats = crawl.ComputeLatencyStats(nil, now)
+	require.Zero(t, stats.Samples)
+	require.Equal(t, crawl.Percentiles{}, stats.Status)
+}
+
+func TestErrorClass(t *testing.T) {
+	require.Equal(t, "", crawl.ErrorClass(nil))
+	require.Equal(t, crawl.ErrorClassCanceled, crawl.ErrorClass(context.Canceled))
+	require.Equal(t, crawl.ErrorClassOther, crawl.ErrorClass(fmt.Errorf("unknown")))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	_, err = net.Dial("tcp", addr)
+	require.Equal(t, crawl.ErrorClassConnRefused, crawl.ErrorClass(err))
+
+	_, err = rpcclient.NewHTTP("http://"+addr, "/websocket").Status()
+	require.Equal(t, crawl.ErrorClassConnRefused, crawl.ErrorClass(err))
+
+	rpcErr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":"","error":{"code":-32603,"message":"Internal error"}}`)
+	}))
+	defer rpcErr.Close()
+
+	_, err = rpcclient.NewHTTP(rpcErr.URL, "/websocket").Status()
+	require.Equal(t, crawl.ErrorClassRPC, crawl.ErrorClass(err))
+
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer invalid.Close()
+
+	_, err = rpcclient.NewHTTP(invalid.URL, "/websocket").Status()
+	require.Equal(t, crawl.ErrorClassInvalidResponse, crawl.ErrorClass(err))
+}
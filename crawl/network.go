@@ -0,0 +1,24 @@
+package crawl
+
+import (
+	"github.com/fissionlabsio/tmcrawl/db"
+)
+
+// NetworkKeyPrefix defines the persistence prefix key of network-scoped data.
+var NetworkKeyPrefix = []byte("network/")
+
+// NetworkKeyPrefixOf constructs the DB key prefix under which all data of a
+// network is persisted.
+func NetworkKeyPrefixOf(chainID string) []byte {
+	return append(append(append([]byte{}, NetworkKeyPrefix...), chainID...), '/')
+}
+
+// NetworkDB returns a DB scoped to the keyspace of a network. The unscoped DB
+// is returned for an empty chain ID, i.e. the default network profile.
+func NetworkDB(root db.DB, chainID string) db.DB {
+	if chainID == "" {
+		return root
+	}
+
+	return db.NewPrefixDB(root, NetworkKeyPrefixOf(chainID))
+}
@@ -1,29 +1,118 @@
 package crawl
 
 import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/fissionlabsio/tmcrawl/db"
 	"github.com/vmihailenco/msgpack/v4"
 )
 
 // Node persistence prefix keys
 var (
 	NodeKeyPrefix     = []byte("node/")
+	AddressKeyPrefix  = []byte("address/")
 	LocationKeyPrefix = []byte("location/")
+	HealthyKeyPrefix  = []byte("healthy/")
+)
+
+// nodeKeysMigratedKey marks a keyspace whose nodes are all keyed by node ID.
+var nodeKeysMigratedKey = []byte("migration/node_keys")
+
+// Node statuses
+const (
+	NodeStatusOnline  = "online"
+	NodeStatusOffline = "offline"
 )
 
+// ErrNodeNotFound defines a sentinel error for when a node cannot be found by
+// its ID or address.
+var ErrNodeNotFound = errors.New("node not found")
+
 type (
 	// Node represents a full-node in a Tendermint-based network that contains
 	// relevant p2p data.
 	Node struct {
-		Address  string   `json:"address" yaml:"address"`
-		RPCPort  string   `json:"rpc_port" yaml:"rpc_port"`
-		P2PPort  string   `json:"p2p_port" yaml:"p2p_port"`
-		Moniker  string   `json:"moniker" yaml:"moniker"`
-		ID       string   `json:"id" yaml:"id"`
-		Network  string   `json:"network" yaml:"network"`
-		Version  string   `json:"version" yaml:"version"`
-		TxIndex  string   `json:"tx_index" yaml:"tx_index"`
-		LastSync string   `json:"last_sync" yaml:"last_sync"`
-		Location Location `json:"location" yaml:"location"`
+		Address   string   `json:"address" yaml:"address"`
+		RPCScheme string   `json:"rpc_scheme" yaml:"rpc_scheme"`
+		RPCPort   string   `json:"rpc_port" yaml:"rpc_port"`
+		P2PPort   string   `json:"p2p_port" yaml:"p2p_port"`
+		Moniker   string   `json:"moniker" yaml:"moniker"`
+		ID        string   `json:"id" yaml:"id"`
+		Network   string   `json:"network" yaml:"network"`
+		Version   string   `json:"version" yaml:"version"`
+		Status    string   `json:"status" yaml:"status"`
+		TxIndex   string   `json:"tx_index" yaml:"tx_index"`
+		LastSync  string   `json:"last_sync" yaml:"last_sync"`
+		Location  Location `json:"location" yaml:"location"`
+
+		Channels   string `json:"channels" yaml:"channels"`
+		ListenAddr string `json:"listen_addr" yaml:"listen_addr"`
+
+		FirstSeen           string `json:"first_seen" yaml:"first_seen"`
+		LastSeen            string `json:"last_seen" yaml:"last_seen"`
+		ConsecutiveFailures uint   `json:"consecutive_failures" yaml:"consecutive_failures"`
+		Uptime              Uptime `json:"uptime" yaml:"uptime"`
+
+		Latency LatencyStats `json:"latency" yaml:"latency"`
+
+		SyncInfo SyncInfo `json:"sync_info" yaml:"sync_info"`
+
+		Role             string `json:"role" yaml:"role"`
+		ValidatorAddress string `json:"validator_address" yaml:"validator_address"`
+		VotingPower      int64  `json:"voting_power" yaml:"voting_power"`
+
+		NetInfo NetInfo `json:"net_info" yaml:"net_info"`
+
+		ProtocolVersion ProtocolVersion `json:"protocol_version" yaml:"protocol_version"`
+		AppInfo         AppInfo         `json:"app_info" yaml:"app_info"`
+		GenesisHash     string          `json:"genesis_hash" yaml:"genesis_hash"`
+
+		RPCExposure RPCExposure `json:"rpc_exposure" yaml:"rpc_exposure"`
+		Services    Services    `json:"services" yaml:"services"`
+		Metadata    Metadata    `json:"metadata" yaml:"metadata"`
+	}
+
+	// ProtocolVersion defines the P2P, block and application protocol versions
+	// a Tendermint node advertises in its NodeInfo.
+	ProtocolVersion struct {
+		P2P   uint64 `json:"p2p" yaml:"p2p"`
+		Block uint64 `json:"block" yaml:"block"`
+		App   uint64 `json:"app" yaml:"app"`
+	}
+
+	// AppInfo defines the ABCI application of a Tendermint node as reported by
+	// its RPC ABCI info at the time of the last crawl, e.g. the name and version
+	// of the gaiad binary it runs.
+	AppInfo struct {
+		Name        string `json:"name" yaml:"name"`
+		Version     string `json:"version" yaml:"version"`
+		BlockHeight int64  `json:"block_height" yaml:"block_height"`
+	}
+
+	// SyncInfo defines the sync status of a Tendermint node as reported by its
+	// RPC status at the time of the last crawl.
+	SyncInfo struct {
+		LatestBlockHash   string `json:"latest_block_hash" yaml:"latest_block_hash"`
+		LatestAppHash     string `json:"latest_app_hash" yaml:"latest_app_hash"`
+		LatestBlockHeight int64  `json:"latest_block_height" yaml:"latest_block_height"`
+		LatestBlockTime   string `json:"latest_block_time" yaml:"latest_block_time"`
+		CatchingUp        bool   `json:"catching_up" yaml:"catching_up"`
+	}
+
+	// NetInfo defines the connectivity of a Tendermint node as reported by its
+	// RPC net info at the time of the last crawl. Rates are in bytes per second
+	// and aggregated over all of the node's peer connections.
+	NetInfo struct {
+		Listeners         []string `json:"listeners" yaml:"listeners"`
+		NumPeers          int      `json:"n_peers" yaml:"n_peers"`
+		InboundPeers      int      `json:"n_inbound_peers" yaml:"n_inbound_peers"`
+		OutboundPeers     int      `json:"n_outbound_peers" yaml:"n_outbound_peers"`
+		SendRate          int64    `json:"send_rate" yaml:"send_rate"`
+		RecvRate          int64    `json:"recv_rate" yaml:"recv_rate"`
+		SendQueueSize     int      `json:"send_queue_size" yaml:"send_queue_size"`
+		SendQueueCapacity int      `json:"send_queue_capacity" yaml:"send_queue_capacity"`
 	}
 
 	// Location defines geolocation information of a Tendermint node.
@@ -36,9 +125,25 @@ type (
 	}
 )
 
-// Key returns the addressable persistence key of a Node.
+// Key returns the persistence key of a Node which is based on its node ID.
 func (n Node) Key() []byte {
-	return NodeKey(n.Address)
+	return NodeKey(n.ID)
+}
+
+// AddressKey returns the persistence key of the index entry from a Node's
+// address and P2P port to its node ID.
+func (n Node) AddressKey() []byte {
+	return AddressKey(n.P2PAddress())
+}
+
+// P2PAddress returns the host:port P2P address of a Node.
+func (n Node) P2PAddress() string {
+	return net.JoinHostPort(n.Address, n.P2PPort)
+}
+
+// RPCAddress returns the scheme://host:port RPC address of a Node.
+func (n Node) RPCAddress() string {
+	return fmt.Sprintf("%s://%s", n.RPCScheme, net.JoinHostPort(n.Address, n.RPCPort))
 }
 
 // Marshal returns the MessagePack encoding of a Node.
@@ -79,12 +184,123 @@ func (l *Location) Unmarshal(bz []byte) error {
 	return nil
 }
 
-// NodeKey constructs the DB key for node persistence.
-func NodeKey(addressable string) []byte {
-	return append(NodeKeyPrefix, []byte(addressable)...)
+// NodeKey constructs the DB key for node persistence by node ID.
+func NodeKey(id string) []byte {
+	return append(NodeKeyPrefix, []byte(id)...)
+}
+
+// AddressKey constructs the DB key for the index from a node's host:port P2P
+// address to its node ID.
+func AddressKey(addressable string) []byte {
+	return append(AddressKeyPrefix, []byte(addressable)...)
+}
+
+// HealthyKey constructs the DB key for the index of healthy nodes, i.e. online
+// nodes whose latest probe succeeded, from a node ID to its P2P address.
+func HealthyKey(id string) []byte {
+	return append(HealthyKeyPrefix, []byte(id)...)
 }
 
 // LocationKey constructs the DB key for location persistence/caching.
 func LocationKey(addressable string) []byte {
 	return append(LocationKeyPrefix, []byte(addressable)...)
 }
+
+// GetNode returns a persisted node by its node ID or address. The address may
+// be in the form of host:port, which is resolved through the address index, or
+// only a host in which case the first node found on that host is returned.
+// ErrNodeNotFound is returned if no node can be found.
+func GetNode(db db.DB, idOrAddress string) (Node, error) {
+	var id []byte
+
+	switch {
+	case db.Has(NodeKey(idOrAddress)):
+		id = []byte(idOrAddress)
+
+	case db.Has(AddressKey(idOrAddress)):
+		bz, err := db.Get(AddressKey(idOrAddress))
+		if err != nil {
+			return Node{}, err
+		}
+
+		id = bz
+
+	default:
+		db.IteratePrefix(AddressKey(idOrAddress+":"), func(_, v []byte) bool {
+			id = v
+			return true
+		})
+	}
+
+	if id == nil || !db.Has(NodeKey(string(id))) {
+		return Node{}, ErrNodeNotFound
+	}
+
+	bz, err := db.Get(NodeKey(string(id)))
+	if err != nil {
+		return Node{}, err
+	}
+
+	node := new(Node)
+	if err := node.Unmarshal(bz); err != nil {
+		return Node{}, err
+	}
+
+	return *node, nil
+}
+
+// MigrateNodeKeys rekeys all nodes of a keyspace which were persisted by
+// address, i.e. under node/<ip> or node/<ip>:<port>, by their node ID along
+// with an address index entry. Such nodes are dropped if their node ID is
+// unknown or a node is already persisted under it. The migration is only
+// performed once per keyspace. The number of migrated and dropped nodes is
+// returned.
+func MigrateNodeKeys(db db.DB) (migrated, dropped int, err error) {
+	if db.Has(nodeKeysMigratedKey) {
+		return 0, 0, nil
+	}
+
+	type legacyNode struct {
+		key  []byte
+		node Node
+	}
+
+	legacy := []legacyNode{}
+	db.IteratePrefix(NodeKeyPrefix, func(k, v []byte) bool {
+		node := new(Node)
+
+		// nodes which cannot be decoded cannot be rekeyed either
+		if err := node.Unmarshal(v); err != nil || string(k[len(NodeKeyPrefix):]) != node.ID {
+			legacy = append(legacy, legacyNode{key: append([]byte{}, k...), node: *node})
+		}
+
+		return false
+	})
+
+	for _, l := range legacy {
+		if l.node.ID != "" && !db.Has(l.node.Key()) {
+			bz, err := l.node.Marshal()
+			if err != nil {
+				return migrated, dropped, err
+			}
+
+			if err := db.Set(l.node.Key(), bz); err != nil {
+				return migrated, dropped, err
+			}
+
+			if err := db.Set(l.node.AddressKey(), []byte(l.node.ID)); err != nil {
+				return migrated, dropped, err
+			}
+
+			migrated++
+		} else {
+			dropped++
+		}
+
+		if err := db.Delete(l.key); err != nil {
+			return migrated, dropped, err
+		}
+	}
+
+	return migrated, dropped, db.Set(nodeKeysMigratedKey, []byte{1})
+}
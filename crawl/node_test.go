@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,8 +17,102 @@ func TestNode_Key(t *testing.T) {
 		Address: "127.0.0.1",
 		RPCPort: "26657",
 		P2PPort: "26656",
+		ID:      "5a8a6061c8a2e2e02d497060d5325b6588051cc6",
 	}
-	require.Equal(t, "node/127.0.0.1", string(n.Key()))
+	require.Equal(t, "node/5a8a6061c8a2e2e02d497060d5325b6588051cc6", string(n.Key()))
+	require.Equal(t, "address/127.0.0.1:26656", string(n.AddressKey()))
+
+	n = crawl.Node{
+		Address: "127.0.0.1",
+		RPCPort: "36657",
+		P2PPort: "36656",
+		ID:      "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b",
+	}
+	require.Equal(t, "address/127.0.0.1:36656", string(n.AddressKey()))
+}
+
+func TestGetNode(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	nodes := []crawl.Node{
+		{Address: "127.0.0.1", P2PPort: "26656", ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6"},
+		{Address: "127.0.0.1", P2PPort: "36656", ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b"},
+	}
+
+	for _, n := range nodes {
+		bz, err := n.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, bdb.Set(n.Key(), bz))
+		require.NoError(t, bdb.Set(n.AddressKey(), []byte(n.ID)))
+	}
+
+	for _, n := range nodes {
+		node, err := crawl.GetNode(bdb, n.ID)
+		require.NoError(t, err)
+		require.Equal(t, n, node)
+
+		node, err = crawl.GetNode(bdb, n.P2PAddress())
+		require.NoError(t, err)
+		require.Equal(t, n, node)
+	}
+
+	node, err := crawl.GetNode(bdb, "127.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", node.Address)
+
+	_, err = crawl.GetNode(bdb, "127.0.0.2")
+	require.Equal(t, crawl.ErrNodeNotFound, err)
+}
+
+func TestMigrateNodeKeys(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	current := crawl.Node{Address: "127.0.0.1", P2PPort: "26656", ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6"}
+	legacy := crawl.Node{Address: "127.0.0.2", P2PPort: "26656", ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b"}
+	duplicate := crawl.Node{Address: "127.0.0.3", P2PPort: "26656", ID: current.ID}
+	anonymous := crawl.Node{Address: "127.0.0.4", P2PPort: "26656"}
+
+	for key, n := range map[string]crawl.Node{
+		string(current.Key()):                    current,
+		string(crawl.NodeKey("127.0.0.2")):       legacy,
+		string(crawl.NodeKey("127.0.0.3:26656")): duplicate,
+		string(crawl.NodeKey(anonymous.Address)): anonymous,
+	} {
+		bz, err := n.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, bdb.Set([]byte(key), bz))
+	}
+
+	migrated, dropped, err := crawl.MigrateNodeKeys(bdb)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+	require.Equal(t, 2, dropped)
+
+	keys := []string{}
+	bdb.IteratePrefix(crawl.NodeKeyPrefix, func(k, _ []byte) bool {
+		keys = append(keys, string(k))
+		return false
+	})
+	require.ElementsMatch(t, []string{string(current.Key()), string(legacy.Key())}, keys)
+
+	node, err := crawl.GetNode(bdb, legacy.P2PAddress())
+	require.NoError(t, err)
+	require.Equal(t, legacy, node)
+
+	node, err = crawl.GetNode(bdb, current.ID)
+	require.NoError(t, err)
+	require.Equal(t, current, node)
+
+	// the migration is only performed once
+	bz, err := anonymous.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, bdb.Set(crawl.NodeKey(anonymous.Address), bz))
+
+	migrated, dropped, err = crawl.MigrateNodeKeys(bdb)
+	require.NoError(t, err)
+	require.Zero(t, migrated+dropped)
 }
 
 func TestNode_Serialize(t *testing.T) {
@@ -38,6 +133,46 @@ func TestNode_Serialize(t *testing.T) {
 			Latitude:  "39.043701",
 			Longitude: "-77.474197",
 		},
+		Channels:   "40202122233038",
+		ListenAddr: "tcp://0.0.0.0:26656",
+		FirstSeen:  time.Now().UTC().Add(-time.Hour).Format(time.RFC3339),
+		LastSeen:   time.Now().UTC().Format(time.RFC3339),
+		Uptime:     crawl.Uptime{Day: 100, Week: 98.5, Month: 97.25},
+		Latency: crawl.LatencyStats{
+			Samples: 2,
+			Status:  crawl.Percentiles{P50: 20, P90: 25.5, P99: 25.5},
+			Errors:  map[string]int{crawl.ErrorClassTimeout: 1},
+		},
+		SyncInfo: crawl.SyncInfo{
+			LatestBlockHash:   "0E1DC2B8F1C8C3C9E1B2A4F0D8E6C5B4A3F2E1D0C9B8A7F6E5D4C3B2A1F0E9D8",
+			LatestAppHash:     "6A5B4C3D2E1F0A9B8C7D6E5F4A3B2C1D0E9F8A7B6C5D4E3F2A1B0C9D8E7F6A5B",
+			LatestBlockHeight: 1234567,
+			LatestBlockTime:   time.Now().UTC().Format(time.RFC3339),
+			CatchingUp:        true,
+		},
+		Role:             crawl.NodeRoleValidator,
+		ValidatorAddress: "B0C2A1F5D7E3B6C4A8F9E0D1C2B3A4F5E6D7C8B9",
+		VotingPower:      1000,
+		NetInfo: crawl.NetInfo{
+			Listeners:         []string{"Listener(@1.2.3.4:26656)"},
+			NumPeers:          3,
+			InboundPeers:      2,
+			OutboundPeers:     1,
+			SendRate:          2048,
+			RecvRate:          4096,
+			SendQueueSize:     5,
+			SendQueueCapacity: 300,
+		},
+		ProtocolVersion: crawl.ProtocolVersion{P2P: 7, Block: 10, App: 0},
+		AppInfo:         crawl.AppInfo{Name: "GaiaApp", Version: "v2.0.8", BlockHeight: 1234567},
+		GenesisHash:     "1C5A2F0E9D8B7C6A5F4E3D2C1B0A9F8E7D6C5B4A3F2E1D0C9B8A7F6E5D4C3B2A",
+		RPCExposure: crawl.RPCExposure{
+			Routes:       []string{"dial_seeds", "status"},
+			UnsafeRoutes: []string{"dial_seeds"},
+			LastAudit:    time.Now().UTC().Format(time.RFC3339),
+		},
+		Services: crawl.Services{REST: "http://127.0.0.1:1317", GRPC: "127.0.0.1:9090"},
+		Metadata: crawl.Metadata{"chain_specific": "value"},
 	}
 
 	bz, err := n.Marshal()
@@ -0,0 +1,275 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/crypto"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/p2p/conn"
+)
+
+const (
+	handshakeTimeout = 5 * time.Second
+	pexTimeout       = 10 * time.Second
+
+	// pexMaxMsgSize defines the maximum size of a PEX message which matches the
+	// PEX reactor's limit of 250 addresses of up to 256 bytes each.
+	pexMaxMsgSize = 256 * 250
+
+	// pexChannel defines the PEX reactor channel. It is the only channel the
+	// crawler advertises.
+	pexChannel = byte(0x00)
+
+	// crawlerListenAddr defines the listen address advertised to peers during
+	// the NodeInfo exchange. It is intentionally non-routable so peers do not
+	// add the crawler to their address books.
+	crawlerListenAddr = "tcp://0.0.0.0:26656"
+	crawlerMoniker    = "tmcrawl"
+)
+
+var p2pCdc = amino.NewCodec()
+
+func init() {
+	registerPexMessage(p2pCdc)
+}
+
+type (
+	// pexMessage defines the PEX reactor message interface. The concrete message
+	// types mirror the unexported types of Tendermint's PEX reactor and are
+	// registered under the same Amino names so they are wire compatible.
+	pexMessage interface{}
+
+	// pexRequestMessage requests peer addresses from a node.
+	pexRequestMessage struct{}
+
+	// pexAddrsMessage contains peer addresses announced by a node.
+	pexAddrsMessage struct {
+		Addrs []*p2p.NetAddress
+	}
+)
+
+func registerPexMessage(cdc *amino.Codec) {
+	cdc.RegisterInterface((*pexMessage)(nil), nil)
+	cdc.RegisterConcrete(&pexRequestMessage{}, "tendermint/p2p/PexRequestMessage", nil)
+	cdc.RegisterConcrete(&pexAddrsMessage{}, "tendermint/p2p/PexAddrsMessage", nil)
+}
+
+// Handshake dials a P2P Tendermint address, upgrades the connection to an
+// authenticated SecretConnection using the provided private key and exchanges
+// NodeInfo with the remote node. The remote node's NodeInfo is returned only if
+// its self-reported ID matches the ID authenticated by the SecretConnection.
+func Handshake(ctx context.Context, dialer Dialer, address string, privKey crypto.PrivKey, timeout time.Duration) (p2p.DefaultNodeInfo, error) {
+	sc, peerInfo, err := DialPeer(ctx, dialer, address, privKey, timeout)
+	if err != nil {
+		return p2p.DefaultNodeInfo{}, err
+	}
+
+	defer sc.Close()
+	return peerInfo, nil
+}
+
+// DialPeer dials a P2P Tendermint address via the dialer and performs the
+// SecretConnection handshake and NodeInfo exchange. The authenticated
+// connection is returned along with the remote node's NodeInfo. The caller is
+// responsible for closing the connection. The handshake is aborted if the
+// context is canceled.
+func DialPeer(ctx context.Context, dialer Dialer, address string, privKey crypto.PrivKey, timeout time.Duration) (*conn.SecretConnection, p2p.DefaultNodeInfo, error) {
+	sc, peerInfo, _, err := dialPeer(ctx, dialer, address, privKey, timeout)
+	return sc, peerInfo, err
+}
+
+// dialPeer implements DialPeer and additionally returns the round-trip time of
+// the TCP connect, which is zero if the connection could not be established.
+func dialPeer(ctx context.Context, dialer Dialer, address string, privKey crypto.PrivKey, timeout time.Duration) (*conn.SecretConnection, p2p.DefaultNodeInfo, time.Duration, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	c, err := dialer.DialContext(dialCtx, "tcp", address)
+	if err != nil {
+		return nil, p2p.DefaultNodeInfo{}, 0, err
+	}
+
+	connectRTT := time.Since(start)
+
+	if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		c.Close()
+		return nil, p2p.DefaultNodeInfo{}, connectRTT, err
+	}
+
+	// unblock the handshake by closing the connection upon cancellation
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+
+		case <-stop:
+		}
+	}()
+
+	sc, err := conn.MakeSecretConnection(c, privKey)
+	if err != nil {
+		c.Close()
+		return nil, p2p.DefaultNodeInfo{}, connectRTT, fmt.Errorf("failed to upgrade secret connection: %w", err)
+	}
+
+	peerInfo, err := exchangeNodeInfo(sc, privKey)
+	if err != nil {
+		sc.Close()
+		return nil, p2p.DefaultNodeInfo{}, connectRTT, fmt.Errorf("failed to exchange node info: %w", err)
+	}
+
+	if connID := p2p.PubKeyToID(sc.RemotePubKey()); connID != peerInfo.ID() {
+		sc.Close()
+		return nil, p2p.DefaultNodeInfo{}, connectRTT, fmt.Errorf("node info ID %s does not match authenticated ID %s", peerInfo.ID(), connID)
+	}
+
+	if err := sc.SetDeadline(time.Time{}); err != nil {
+		sc.Close()
+		return nil, p2p.DefaultNodeInfo{}, connectRTT, err
+	}
+
+	return sc, peerInfo, connectRTT, nil
+}
+
+// exchangeNodeInfo reads the remote node's NodeInfo and replies with the
+// crawler's own NodeInfo. Unlike Tendermint, which writes and reads
+// concurrently, the remote NodeInfo is read first so the crawler can mirror the
+// remote node's network and protocol version and thus be considered compatible.
+func exchangeNodeInfo(sc *conn.SecretConnection, privKey crypto.PrivKey) (p2p.DefaultNodeInfo, error) {
+	var peerInfo p2p.DefaultNodeInfo
+
+	if _, err := p2pCdc.UnmarshalBinaryLengthPrefixedReader(sc, &peerInfo, int64(p2p.MaxNodeInfoSize())); err != nil {
+		return p2p.DefaultNodeInfo{}, err
+	}
+
+	ourInfo := p2p.DefaultNodeInfo{
+		ProtocolVersion: peerInfo.ProtocolVersion,
+		ID_:             p2p.PubKeyToID(privKey.PubKey()),
+		ListenAddr:      crawlerListenAddr,
+		Network:         peerInfo.Network,
+		Version:         peerInfo.Version,
+		Channels:        []byte{pexChannel},
+		Moniker:         crawlerMoniker,
+	}
+
+	if _, err := p2pCdc.MarshalBinaryLengthPrefixedWriter(sc, ourInfo); err != nil {
+		return p2p.DefaultNodeInfo{}, err
+	}
+
+	return peerInfo, nil
+}
+
+// SupportsPEX returns true if a node advertises the PEX reactor channel.
+func SupportsPEX(nodeInfo p2p.DefaultNodeInfo) bool {
+	for _, ch := range nodeInfo.Channels {
+		if ch == pexChannel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequestAddrs speaks the PEX reactor protocol over an authenticated connection
+// returned by DialPeer. It sends a single pexRequestMessage and waits for the
+// node to reply with a pexAddrsMessage. Only valid and routable addresses are
+// returned. The connection is closed once the exchange completes or the
+// context is canceled.
+func RequestAddrs(ctx context.Context, sc *conn.SecretConnection, timeout time.Duration) ([]*p2p.NetAddress, error) {
+	addrsCh := make(chan []*p2p.NetAddress, 1)
+	errCh := make(chan error, 1)
+
+	onReceive := func(chID byte, bz []byte) {
+		if chID != pexChannel {
+			return
+		}
+
+		msg, err := decodePexMsg(bz)
+		if err != nil {
+			select {
+			case errCh <- err:
+			default:
+			}
+
+			return
+		}
+
+		// ignore any requests the node makes of the crawler
+		if m, ok := msg.(*pexAddrsMessage); ok {
+			select {
+			case addrsCh <- m.Addrs:
+			default:
+			}
+		}
+	}
+
+	onError := func(r interface{}) {
+		select {
+		case errCh <- fmt.Errorf("connection error: %v", r):
+		default:
+		}
+	}
+
+	chDescs := []*conn.ChannelDescriptor{
+		{ID: pexChannel, Priority: 1, SendQueueCapacity: 10, RecvMessageCapacity: pexMaxMsgSize},
+	}
+
+	mconn := conn.NewMConnection(sc, chDescs, onReceive, onError)
+	mconn.SetLogger(tmlog.NewNopLogger())
+
+	if err := mconn.Start(); err != nil {
+		return nil, err
+	}
+
+	defer mconn.Stop() // nolint: errcheck
+
+	if !mconn.Send(pexChannel, p2pCdc.MustMarshalBinaryBare(&pexRequestMessage{})) {
+		return nil, errors.New("failed to send PEX request")
+	}
+
+	select {
+	case addrs := <-addrsCh:
+		return filterAddrs(addrs), nil
+
+	case err := <-errCh:
+		return nil, err
+
+	case <-time.After(timeout):
+		return nil, errors.New("timed out waiting for PEX addresses")
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func decodePexMsg(bz []byte) (msg pexMessage, err error) {
+	if len(bz) > pexMaxMsgSize {
+		return msg, fmt.Errorf("PEX message exceeds max size (%d > %d)", len(bz), pexMaxMsgSize)
+	}
+
+	err = p2pCdc.UnmarshalBinaryBare(bz, &msg)
+	return msg, err
+}
+
+func filterAddrs(addrs []*p2p.NetAddress) []*p2p.NetAddress {
+	filtered := make([]*p2p.NetAddress, 0, len(addrs))
+
+	for _, addr := range addrs {
+		if addr == nil || addr.Valid() != nil || !addr.Routable() {
+			continue
+		}
+
+		filtered = append(filtered, addr)
+	}
+
+	return filtered
+}
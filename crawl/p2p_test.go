@@ -0,0 +1,168 @@
+package crawl_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/p2p/conn"
+)
+
+var testCdc = amino.NewCodec()
+
+type (
+	testPexMessage        interface{}
+	testPexRequestMessage struct{}
+	testPexAddrsMessage   struct {
+		Addrs []*p2p.NetAddress
+	}
+)
+
+func init() {
+	testCdc.RegisterInterface((*testPexMessage)(nil), nil)
+	testCdc.RegisterConcrete(&testPexRequestMessage{}, "tendermint/p2p/PexRequestMessage", nil)
+	testCdc.RegisterConcrete(&testPexAddrsMessage{}, "tendermint/p2p/PexAddrsMessage", nil)
+}
+
+// startTestPeer starts a listener that mimics a Tendermint node's P2P transport
+// by performing the SecretConnection handshake and exchanging NodeInfo. The
+// NodeInfo received from the dialer is sent on the returned channel. If
+// pexAddrs is non-nil, the peer replies to a PEX request with those addresses.
+func startTestPeer(
+	t *testing.T, nodeInfo func(id p2p.ID) p2p.DefaultNodeInfo, pexAddrs []*p2p.NetAddress,
+) (string, p2p.ID, <-chan p2p.DefaultNodeInfo) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	privKey := ed25519.GenPrivKey()
+	id := p2p.PubKeyToID(privKey.PubKey())
+	received := make(chan p2p.DefaultNodeInfo, 1)
+
+	go func() {
+		defer ln.Close()
+
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		sc, err := conn.MakeSecretConnection(c, privKey)
+		if err != nil {
+			return
+		}
+
+		if _, err := testCdc.MarshalBinaryLengthPrefixedWriter(sc, nodeInfo(id)); err != nil {
+			return
+		}
+
+		var other p2p.DefaultNodeInfo
+		if _, err := testCdc.UnmarshalBinaryLengthPrefixedReader(sc, &other, int64(p2p.MaxNodeInfoSize())); err != nil {
+			return
+		}
+
+		received <- other
+
+		if pexAddrs == nil {
+			return
+		}
+
+		done := make(chan struct{})
+
+		var mconn *conn.MConnection
+		onReceive := func(chID byte, bz []byte) {
+			var msg testPexMessage
+			if err := testCdc.UnmarshalBinaryBare(bz, &msg); err != nil {
+				return
+			}
+
+			if _, ok := msg.(*testPexRequestMessage); ok {
+				mconn.Send(chID, testCdc.MustMarshalBinaryBare(&testPexAddrsMessage{Addrs: pexAddrs}))
+			}
+		}
+		onError := func(_ interface{}) { close(done) }
+
+		chDescs := []*conn.ChannelDescriptor{{ID: 0x00, Priority: 1}}
+		mconn = conn.NewMConnection(sc, chDescs, onReceive, onError)
+		mconn.SetLogger(log.NewNopLogger())
+
+		if err := mconn.Start(); err != nil {
+			return
+		}
+
+		<-done
+	}()
+
+	return ln.Addr().String(), id, received
+}
+
+func TestHandshake(t *testing.T) {
+	addr, id, received := startTestPeer(t, func(id p2p.ID) p2p.DefaultNodeInfo {
+		return p2p.DefaultNodeInfo{
+			ID_:        id,
+			ListenAddr: "tcp://0.0.0.0:26656",
+			Network:    "chain-0",
+			Version:    "0.32.8",
+			Channels:   []byte{0x00, 0x20},
+			Moniker:    "test-node-0",
+		}
+	}, nil)
+
+	nodeInfo, err := crawl.Handshake(context.Background(), &net.Dialer{}, addr, ed25519.GenPrivKey(), time.Second)
+	require.NoError(t, err)
+	require.Equal(t, id, nodeInfo.ID())
+	require.Equal(t, "chain-0", nodeInfo.Network)
+	require.Equal(t, "0.32.8", nodeInfo.Version)
+	require.Equal(t, "0020", nodeInfo.Channels.String())
+	require.Equal(t, "tcp://0.0.0.0:26656", nodeInfo.ListenAddr)
+
+	ourInfo := <-received
+	require.Equal(t, "chain-0", ourInfo.Network)
+	require.Equal(t, "0.32.8", ourInfo.Version)
+}
+
+func TestHandshake_IDMismatch(t *testing.T) {
+	addr, _, _ := startTestPeer(t, func(_ p2p.ID) p2p.DefaultNodeInfo {
+		return p2p.DefaultNodeInfo{
+			ID_:        p2p.PubKeyToID(ed25519.GenPrivKey().PubKey()),
+			ListenAddr: "tcp://0.0.0.0:26656",
+			Network:    "chain-0",
+		}
+	}, nil)
+
+	_, err := crawl.Handshake(context.Background(), &net.Dialer{}, addr, ed25519.GenPrivKey(), time.Second)
+	require.Error(t, err)
+}
+
+func TestRequestAddrs(t *testing.T) {
+	id := p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())
+	routable, err := p2p.NewNetAddressString(p2p.IDAddressString(id, "8.8.8.8:26656"))
+	require.NoError(t, err)
+	private, err := p2p.NewNetAddressString(p2p.IDAddressString(id, "192.168.1.1:26656"))
+	require.NoError(t, err)
+
+	addr, _, _ := startTestPeer(t, func(id p2p.ID) p2p.DefaultNodeInfo {
+		return p2p.DefaultNodeInfo{
+			ID_:        id,
+			ListenAddr: "tcp://0.0.0.0:26656",
+			Network:    "chain-0",
+			Channels:   []byte{0x00},
+		}
+	}, []*p2p.NetAddress{routable, private})
+
+	sc, nodeInfo, err := crawl.DialPeer(context.Background(), &net.Dialer{}, addr, ed25519.GenPrivKey(), time.Second)
+	require.NoError(t, err)
+	require.True(t, crawl.SupportsPEX(nodeInfo))
+
+	addrs, err := crawl.RequestAddrs(context.Background(), sc, 5*time.Second)
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	require.Equal(t, routable.String(), addrs[0].String())
+}
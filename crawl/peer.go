@@ -0,0 +1,146 @@
+package crawl
+
+import (
+	"net"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/db"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// PeerKeyPrefix defines the persistence prefix key of peer graph edges.
+var PeerKeyPrefix = []byte("peer/")
+
+// Peer defines a directed edge of the peer graph from a crawled node to one of
+// the peers it reported being connected to during its last crawl. Rates are in
+// bytes per second.
+type Peer struct {
+	ID                 string `json:"id" yaml:"id"`
+	Address            string `json:"address" yaml:"address"`
+	P2PPort            string `json:"p2p_port" yaml:"p2p_port"`
+	Moniker            string `json:"moniker" yaml:"moniker"`
+	Outbound           bool   `json:"is_outbound" yaml:"is_outbound"`
+	ConnectionDuration int64  `json:"connection_duration_ms" yaml:"connection_duration_ms"`
+	SendRate           int64  `json:"send_rate" yaml:"send_rate"`
+	RecvRate           int64  `json:"recv_rate" yaml:"recv_rate"`
+	LastSync           string `json:"last_sync" yaml:"last_sync"`
+}
+
+// NewPeer returns a Peer edge from a peer reported by a node's net info at the
+// given time.
+func NewPeer(p ctypes.Peer, t time.Time) Peer {
+	p2pPort := parseListenPort(p.NodeInfo.ListenAddr)
+	if p2pPort == "" {
+		p2pPort = defaultP2PPort
+	}
+
+	return Peer{
+		ID:                 string(p.NodeInfo.ID()),
+		Address:            p.RemoteIP,
+		P2PPort:            p2pPort,
+		Moniker:            p.NodeInfo.Moniker,
+		Outbound:           p.IsOutbound,
+		ConnectionDuration: p.ConnectionStatus.Duration.Milliseconds(),
+		SendRate:           p.ConnectionStatus.SendMonitor.CurRate,
+		RecvRate:           p.ConnectionStatus.RecvMonitor.CurRate,
+		LastSync:           t.UTC().Format(time.RFC3339),
+	}
+}
+
+// P2PAddress returns the host:port P2P address of a Peer.
+func (p Peer) P2PAddress() string {
+	return net.JoinHostPort(p.Address, p.P2PPort)
+}
+
+// Marshal returns the MessagePack encoding of a Peer.
+func (p Peer) Marshal() ([]byte, error) {
+	bz, err := msgpack.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return bz, nil
+}
+
+// Unmarshal unmarshals a MessagePack encoding of a Peer.
+func (p *Peer) Unmarshal(bz []byte) error {
+	if err := msgpack.Unmarshal(bz, p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PeerKey constructs the DB key of the edge from a node to one of its peers.
+func PeerKey(id, peerID string) []byte {
+	return append(PeerNodeKeyPrefix(id), []byte(peerID)...)
+}
+
+// PeerNodeKeyPrefix constructs the DB key prefix of all edges from a node.
+func PeerNodeKeyPrefix(id string) []byte {
+	return append(append(PeerKeyPrefix, []byte(id)...), '/')
+}
+
+// GetPeers returns all persisted edges from a node to its peers.
+func GetPeers(db db.DB, id string) ([]Peer, error) {
+	peers := []Peer{}
+
+	var err error
+	db.IteratePrefix(PeerNodeKeyPrefix(id), func(_, v []byte) bool {
+		p := new(Peer)
+
+		err = p.Unmarshal(v)
+		if err != nil {
+			return true
+		}
+
+		peers = append(peers, *p)
+		return false
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return peers, nil
+}
+
+// SavePeers replaces all persisted edges from a node with the given set of
+// peers.
+func (c *Crawler) SavePeers(id string, peers []Peer) error {
+	if err := c.deletePeers(id); err != nil {
+		return err
+	}
+
+	for _, p := range peers {
+		bz, err := p.Marshal()
+		if err != nil {
+			return err
+		}
+
+		if err := c.db.Set(PeerKey(id, p.ID), bz); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deletePeers removes all persisted edges from a node.
+func (c *Crawler) deletePeers(id string) error {
+	keys := [][]byte{}
+
+	c.db.IteratePrefix(PeerNodeKeyPrefix(id), func(k, _ []byte) bool {
+		keys = append(keys, append([]byte{}, k...))
+		return false
+	})
+
+	for _, k := range keys {
+		if err := c.db.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
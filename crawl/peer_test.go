@@ -0,0 +1,79 @@
+package crawl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+	flow "github.com/tendermint/tendermint/libs/flowrate"
+	"github.com/tendermint/tendermint/p2p"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func TestNewPeer(t *testing.T) {
+	now := time.Now().UTC()
+	p := ctypes.Peer{
+		NodeInfo: p2p.DefaultNodeInfo{
+			ID_:        "5a8a6061c8a2e2e02d497060d5325b6588051cc6",
+			ListenAddr: "tcp://0.0.0.0:36656",
+			Moniker:    "test-node-0",
+		},
+		IsOutbound: true,
+		ConnectionStatus: p2p.ConnectionStatus{
+			Duration:    90 * time.Second,
+			SendMonitor: flow.Status{CurRate: 1024},
+			RecvMonitor: flow.Status{CurRate: 2048},
+		},
+		RemoteIP: "127.0.0.1",
+	}
+
+	peer := crawl.NewPeer(p, now)
+	require.Equal(t, crawl.Peer{
+		ID:                 "5a8a6061c8a2e2e02d497060d5325b6588051cc6",
+		Address:            "127.0.0.1",
+		P2PPort:            "36656",
+		Moniker:            "test-node-0",
+		Outbound:           true,
+		ConnectionDuration: 90000,
+		SendRate:           1024,
+		RecvRate:           2048,
+		LastSync:           now.Format(time.RFC3339),
+	}, peer)
+	require.Equal(t, "127.0.0.1:36656", peer.P2PAddress())
+
+	p.NodeInfo.ListenAddr = ""
+	require.Equal(t, "26656", crawl.NewPeer(p, now).P2PPort)
+}
+
+func TestCrawler_SavePeers(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{}, bdb)
+	id := "5a8a6061c8a2e2e02d497060d5325b6588051cc6"
+
+	peers := []crawl.Peer{
+		{ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", Address: "127.0.0.2", P2PPort: "26656", Outbound: true},
+		{ID: "9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d", Address: "127.0.0.3", P2PPort: "26656"},
+	}
+
+	require.NoError(t, c.SavePeers(id, peers))
+
+	res, err := crawl.GetPeers(bdb, id)
+	require.NoError(t, err)
+	require.Equal(t, peers, res)
+
+	// saving peers replaces the previously persisted edges
+	require.NoError(t, c.SavePeers(id, peers[1:]))
+
+	res, err = crawl.GetPeers(bdb, id)
+	require.NoError(t, err)
+	require.Equal(t, peers[1:], res)
+
+	res, err = crawl.GetPeers(bdb, peers[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, res)
+}
@@ -2,18 +2,37 @@ package crawl
 
 import (
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/rs/zerolog/log"
+)
+
+// Node pool persistence prefix keys
+var (
+	PoolPendingKeyPrefix = []byte("pool/pending/")
+	PoolReseedKeyPrefix  = []byte("pool/reseed/")
 )
 
 // NodePool implements an abstraction over a pool of nodes for which to crawl.
 // It also contains a collection of nodes for which to reseed the pool when it's
 // empty. Once the reseed list has reached capacity, a random node is removed
-// when another is added. Note, it is not thread-safe.
+// when another is added. Nodes that are claimed by a crawl worker are tracked
+// as in-flight by their host so that the same node is never crawled twice at
+// once, even if it is pending under both its RPC and its P2P address. A node
+// added again while in-flight remains pending once it is released. A pool
+// loaded via LoadNodePool writes its pending nodes and reseed list through to
+// the database so they survive restarts.
 type NodePool struct {
 	rw sync.RWMutex
 
+	db          db.DB
 	nodes       map[string]struct{}
+	inFlight    map[string]struct{}
+	hosts       map[string]struct{}
+	readded     map[string]struct{}
 	reseedNodes []string
 	rng         *rand.Rand
 }
@@ -21,11 +40,71 @@ type NodePool struct {
 func NewNodePool(reseedCap uint) *NodePool {
 	return &NodePool{
 		nodes:       make(map[string]struct{}),
+		inFlight:    make(map[string]struct{}),
+		hosts:       make(map[string]struct{}),
+		readded:     make(map[string]struct{}),
 		reseedNodes: make([]string, 0, reseedCap),
 		rng:         rand.New(rand.NewSource(time.Now().Unix())),
 	}
 }
 
+// LoadNodePool returns a NodePool backed by the provided database. Any pending
+// nodes and reseed nodes persisted by a previous process are restored. Nodes
+// that were in-flight when the previous process stopped are pending again.
+func LoadNodePool(db db.DB, reseedCap uint) *NodePool {
+	p := NewNodePool(reseedCap)
+
+	db.IteratePrefix(PoolPendingKeyPrefix, func(k, _ []byte) bool {
+		p.nodes[string(k[len(PoolPendingKeyPrefix):])] = struct{}{}
+		return false
+	})
+
+	db.IteratePrefix(PoolReseedKeyPrefix, func(k, _ []byte) bool {
+		if len(p.reseedNodes) == cap(p.reseedNodes) {
+			return true
+		}
+
+		p.reseedNodes = append(p.reseedNodes, string(k[len(PoolReseedKeyPrefix):]))
+		return false
+	})
+
+	p.db = db
+	return p
+}
+
+// PoolPendingKey constructs the DB key of a pending node in the node pool.
+func PoolPendingKey(nodeAddr string) []byte {
+	return append(PoolPendingKeyPrefix, []byte(nodeAddr)...)
+}
+
+// PoolReseedKey constructs the DB key of a node in the reseed list.
+func PoolReseedKey(nodeAddr string) []byte {
+	return append(PoolReseedKeyPrefix, []byte(nodeAddr)...)
+}
+
+// persist sets a key if the pool is backed by a database. Failures are logged
+// as the in-memory pool remains authoritative for the running process.
+func (p *NodePool) persist(key []byte) {
+	if p.db == nil {
+		return
+	}
+
+	if err := p.db.Set(key, []byte{}); err != nil {
+		log.Info().Err(err).Str("key", string(key)).Msg("failed to persist node pool entry")
+	}
+}
+
+// unpersist deletes a key if the pool is backed by a database.
+func (p *NodePool) unpersist(key []byte) {
+	if p.db == nil {
+		return
+	}
+
+	if err := p.db.Delete(key); err != nil {
+		log.Info().Err(err).Str("key", string(key)).Msg("failed to delete node pool entry")
+	}
+}
+
 // Size returns the size of the pool.
 func (p *NodePool) Size() int {
 	p.rw.RLock()
@@ -52,20 +131,115 @@ func (p *NodePool) RandomNode() (string, bool) {
 	return "", false
 }
 
+// poolHost returns the key by which a node address is claimed, which is the
+// host of either its RPC or its P2P address. Addresses that cannot be parsed
+// are claimed as is.
+func poolHost(nodeAddr string) string {
+	_, host, _, _, _ := parseNodeAddr(nodeAddr)
+	if host == "" {
+		return nodeAddr
+	}
+
+	return strings.ToLower(host)
+}
+
+// ClaimNode returns a random node from the pool whose host is not currently
+// being crawled and marks it as in-flight. The node must be released via
+// ReleaseNode once the crawl completes.
+func (p *NodePool) ClaimNode() (string, bool) {
+	p.rw.Lock()
+	defer p.rw.Unlock()
+
+	for nodeRPCAddr := range p.nodes {
+		host := poolHost(nodeRPCAddr)
+		if _, ok := p.hosts[host]; ok {
+			continue
+		}
+
+		p.inFlight[nodeRPCAddr] = struct{}{}
+		p.hosts[host] = struct{}{}
+		return nodeRPCAddr, true
+	}
+
+	return "", false
+}
+
+// ReleaseNode removes a claimed node from the in-flight set. The node is
+// removed from the pool unless it was added again while in-flight.
+func (p *NodePool) ReleaseNode(nodeRPCAddr string) {
+	p.rw.Lock()
+	defer p.rw.Unlock()
+
+	delete(p.inFlight, nodeRPCAddr)
+	delete(p.hosts, poolHost(nodeRPCAddr))
+
+	if _, ok := p.readded[nodeRPCAddr]; ok {
+		delete(p.readded, nodeRPCAddr)
+		return
+	}
+
+	delete(p.nodes, nodeRPCAddr)
+	p.unpersist(PoolPendingKey(nodeRPCAddr))
+}
+
+// IsInFlight returns a boolean based on if a node RPC address is currently
+// claimed by a crawl worker.
+func (p *NodePool) IsInFlight(nodeRPCAddr string) bool {
+	p.rw.RLock()
+	defer p.rw.RUnlock()
+
+	_, ok := p.inFlight[nodeRPCAddr]
+	return ok
+}
+
+// Idle returns true if the pool contains no nodes to crawl and no nodes are
+// currently in-flight.
+func (p *NodePool) Idle() bool {
+	p.rw.RLock()
+	defer p.rw.RUnlock()
+	return len(p.nodes) == 0 && len(p.inFlight) == 0
+}
+
 // AddNode adds a node RPC address to the node pool. In addition, it adds the
 // node to the reseed list. If the reseed list is full, it replaces a random node.
 func (p *NodePool) AddNode(nodeRPCAddr string) {
 	p.rw.Lock()
 	defer p.rw.Unlock()
 
-	p.nodes[nodeRPCAddr] = struct{}{}
+	p.addPending(nodeRPCAddr)
+
+	for _, addr := range p.reseedNodes {
+		if addr == nodeRPCAddr {
+			return
+		}
+	}
 
 	if len(p.reseedNodes) < cap(p.reseedNodes) {
 		p.reseedNodes = append(p.reseedNodes, nodeRPCAddr)
-	} else {
+	} else if len(p.reseedNodes) > 0 {
 		// replace random node with the new node
 		i := p.rng.Intn(len(p.reseedNodes))
+		p.unpersist(PoolReseedKey(p.reseedNodes[i]))
 		p.reseedNodes[i] = nodeRPCAddr
+	} else {
+		return
+	}
+
+	p.persist(PoolReseedKey(nodeRPCAddr))
+}
+
+// addPending adds a node to the pending nodes. A node that is in-flight is
+// recorded as added again so that it remains pending once released. The
+// caller must hold the write lock.
+func (p *NodePool) addPending(nodeRPCAddr string) {
+	if _, ok := p.inFlight[nodeRPCAddr]; ok {
+		p.readded[nodeRPCAddr] = struct{}{}
+		return
+	}
+
+	if _, ok := p.nodes[nodeRPCAddr]; !ok {
+		p.nodes[nodeRPCAddr] = struct{}{}
+		p.persist(PoolPendingKey(nodeRPCAddr))
 	}
 }
 
@@ -82,7 +256,10 @@ func (p *NodePool) HasNode(nodeRPCAddr string) bool {
 func (p *NodePool) DeleteNode(nodeRPCAddr string) {
 	p.rw.Lock()
 	defer p.rw.Unlock()
+
 	delete(p.nodes, nodeRPCAddr)
+	delete(p.readded, nodeRPCAddr)
+	p.unpersist(PoolPendingKey(nodeRPCAddr))
 }
 
 // Reseed seeds the node pool with all the nodes found in the internal reseed
@@ -92,6 +269,6 @@ func (p *NodePool) Reseed() {
 	defer p.rw.Unlock()
 
 	for _, addr := range p.reseedNodes {
-		p.nodes[addr] = struct{}{}
+		p.addPending(addr)
 	}
 }
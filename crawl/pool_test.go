@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
 	"github.com/stretchr/testify/require"
 )
 
@@ -88,3 +89,108 @@ func TestNodePool_Reseed(t *testing.T) {
 	np.Reseed()
 	require.Equal(t, reseedSize, uint(np.Size()))
 }
+
+func TestNodePool_ClaimNode(t *testing.T) {
+	np := crawl.NewNodePool(10)
+	require.True(t, np.Idle())
+
+	np.AddNode("127.0.0.1:26657")
+	np.AddNode("127.0.0.2:26657")
+
+	first, ok := np.ClaimNode()
+	require.True(t, ok)
+	require.True(t, np.IsInFlight(first))
+
+	second, ok := np.ClaimNode()
+	require.True(t, ok)
+	require.NotEqual(t, first, second)
+
+	// all remaining nodes are in-flight
+	_, ok = np.ClaimNode()
+	require.False(t, ok)
+	require.False(t, np.Idle())
+
+	np.ReleaseNode(first)
+	require.False(t, np.HasNode(first))
+	require.False(t, np.IsInFlight(first))
+	require.False(t, np.Idle())
+
+	np.ReleaseNode(second)
+	require.True(t, np.Idle())
+}
+
+func TestNodePool_ReleaseNode_Readded(t *testing.T) {
+	np := crawl.NewNodePool(10)
+	np.AddNode("127.0.0.1:26657")
+
+	addr, ok := np.ClaimNode()
+	require.True(t, ok)
+
+	// a node added again while in-flight remains pending once released
+	np.AddNode(addr)
+	np.ReleaseNode(addr)
+	require.True(t, np.HasNode(addr))
+	require.False(t, np.IsInFlight(addr))
+
+	addr, ok = np.ClaimNode()
+	require.True(t, ok)
+
+	np.ReleaseNode(addr)
+	require.False(t, np.HasNode(addr))
+	require.True(t, np.Idle())
+}
+
+func TestNodePool_ClaimNode_SameHost(t *testing.T) {
+	np := crawl.NewNodePool(10)
+	np.AddNode("http://127.0.0.1:26657")
+	np.AddNode("5a8a6061c8a2e2e02d497060d5325b6588051cc6@127.0.0.1:26656")
+
+	// the RPC and P2P addresses of a host are never claimed at once
+	first, ok := np.ClaimNode()
+	require.True(t, ok)
+
+	_, ok = np.ClaimNode()
+	require.False(t, ok)
+
+	np.ReleaseNode(first)
+
+	second, ok := np.ClaimNode()
+	require.True(t, ok)
+	require.NotEqual(t, first, second)
+}
+
+func TestLoadNodePool(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+	defer bdb.Close()
+
+	np := crawl.LoadNodePool(bdb, 2)
+	np.AddNode("127.0.0.1:26657")
+	np.AddNode("127.0.0.2:26657")
+	np.AddNode("127.0.0.3:26657")
+
+	claimed, ok := np.ClaimNode()
+	require.True(t, ok)
+
+	released, ok := np.ClaimNode()
+	require.True(t, ok)
+	np.ReleaseNode(released)
+
+	// the in-flight node is restored as pending along with the unclaimed node
+	restored := crawl.LoadNodePool(bdb, 2)
+	require.Equal(t, 2, restored.Size())
+	require.True(t, restored.HasNode(claimed))
+	require.False(t, restored.HasNode(released))
+	require.False(t, restored.Idle())
+
+	for _, addr := range []string{"127.0.0.1:26657", "127.0.0.2:26657", "127.0.0.3:26657"} {
+		restored.DeleteNode(addr)
+	}
+
+	// the reseed list is capped and restored independently of pending nodes
+	restored = crawl.LoadNodePool(bdb, 2)
+	require.Equal(t, 0, restored.Size())
+
+	restored.Reseed()
+	require.Equal(t, 2, restored.Size())
+}
@@ -0,0 +1,181 @@
+package crawl
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// probeHistoryWindow defines how long probe results are retained for. It also
+// defines the longest window uptime is computed over.
+const probeHistoryWindow = 30 * 24 * time.Hour
+
+// ProbeKeyPrefix defines the persistence prefix key of probe results.
+var ProbeKeyPrefix = []byte("probe/")
+
+type (
+	// ProbeResult defines the outcome of a single attempt to reach a node. Along
+	// with the latency of the P2P handshake, it contains the round-trip times of
+	// the TCP connect to the node's P2P port and of its /status and /net_info RPC
+	// calls in milliseconds, where zero denotes a step that was not reached. If
+	// a step failed, its stage and error class are recorded.
+	ProbeResult struct {
+		Timestamp  string  `json:"timestamp" yaml:"timestamp"`
+		Latency    int64   `json:"latency_ms" yaml:"latency_ms"`
+		Success    bool    `json:"success" yaml:"success"`
+		ConnectRTT float64 `json:"connect_rtt_ms,omitempty" yaml:"connect_rtt_ms,omitempty"`
+		StatusRTT  float64 `json:"status_rtt_ms,omitempty" yaml:"status_rtt_ms,omitempty"`
+		NetInfoRTT float64 `json:"net_info_rtt_ms,omitempty" yaml:"net_info_rtt_ms,omitempty"`
+		ErrorStage string  `json:"error_stage,omitempty" yaml:"error_stage,omitempty"`
+		ErrorClass string  `json:"error_class,omitempty" yaml:"error_class,omitempty"`
+	}
+
+	// Uptime defines the percentage of successful probes of a node over rolling
+	// windows.
+	Uptime struct {
+		Day   float64 `json:"24h" yaml:"24h"`
+		Week  float64 `json:"7d" yaml:"7d"`
+		Month float64 `json:"30d" yaml:"30d"`
+	}
+)
+
+// NewProbeResult returns a ProbeResult for a probe made at the given time.
+func NewProbeResult(t time.Time, latency time.Duration, success bool) ProbeResult {
+	return ProbeResult{
+		Timestamp: t.UTC().Format(time.RFC3339),
+		Latency:   latency.Milliseconds(),
+		Success:   success,
+	}
+}
+
+// SetError records the stage at which a probe failed along with the class of
+// the error.
+func (r *ProbeResult) SetError(stage string, err error) {
+	r.ErrorStage = stage
+	r.ErrorClass = ErrorClass(err)
+}
+
+// Time returns the time at which the probe was made.
+func (r ProbeResult) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339, r.Timestamp)
+	return t
+}
+
+// Marshal returns the MessagePack encoding of a ProbeResult.
+func (r ProbeResult) Marshal() ([]byte, error) {
+	bz, err := msgpack.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return bz, nil
+}
+
+// Unmarshal unmarshals a MessagePack encoding of a ProbeResult.
+func (r *ProbeResult) Unmarshal(bz []byte) error {
+	if err := msgpack.Unmarshal(bz, r); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ProbeKey constructs the DB key for a node's probe result at a given time.
+// Keys of a node are ordered by time.
+func ProbeKey(id string, t time.Time) []byte {
+	ts := make([]byte, 8)
+	binary.BigEndian.PutUint64(ts, uint64(t.UnixNano()))
+
+	return append(ProbeNodeKeyPrefix(id), ts...)
+}
+
+// ProbeNodeKeyPrefix constructs the DB key prefix of all probe results of a
+// node.
+func ProbeNodeKeyPrefix(id string) []byte {
+	return append(append(ProbeKeyPrefix, []byte(id)...), '/')
+}
+
+// GetProbeHistory returns all persisted probe results of a node made at or
+// after the given time ordered from oldest to newest.
+func GetProbeHistory(db db.DB, id string, since time.Time) ([]ProbeResult, error) {
+	results := []ProbeResult{}
+	start := ProbeKey(id, since)
+
+	var err error
+	db.IteratePrefix(ProbeNodeKeyPrefix(id), func(k, v []byte) bool {
+		if string(k) < string(start) {
+			return false
+		}
+
+		r := new(ProbeResult)
+
+		err = r.Unmarshal(v)
+		if err != nil {
+			return true
+		}
+
+		results = append(results, *r)
+		return false
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// computeUptime returns the percentage of successful probes made at or after
+// the given time. Zero is returned if no probes were made in that window.
+func computeUptime(history []ProbeResult, since time.Time) float64 {
+	var total, ok int
+
+	for _, r := range history {
+		if r.Time().Before(since) {
+			continue
+		}
+
+		total++
+		if r.Success {
+			ok++
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return float64(ok) / float64(total) * 100
+}
+
+// updateProbeStats sets a node's availability statistics from its probe
+// history. The history is expected to be ordered from oldest to newest.
+func updateProbeStats(n *Node, history []ProbeResult, now time.Time) {
+	n.ConsecutiveFailures = 0
+
+	for i := len(history) - 1; i >= 0 && !history[i].Success; i-- {
+		n.ConsecutiveFailures++
+	}
+
+	for _, r := range history {
+		if !r.Success {
+			continue
+		}
+
+		if n.FirstSeen == "" {
+			n.FirstSeen = r.Timestamp
+		}
+
+		n.LastSeen = r.Timestamp
+	}
+
+	n.Uptime = Uptime{
+		Day:   computeUptime(history, now.Add(-24*time.Hour)),
+		Week:  computeUptime(history, now.Add(-7*24*time.Hour)),
+		Month: computeUptime(history, now.Add(-probeHistoryWindow)),
+	}
+
+	n.Latency = ComputeLatencyStats(history, now.Add(-24*time.Hour))
+}
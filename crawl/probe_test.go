@@ -0,0 +1,118 @@
+package crawl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeResult_Serialize(t *testing.T) {
+	r := crawl.NewProbeResult(time.Now(), 150*time.Millisecond, true)
+	require.Equal(t, int64(150), r.Latency)
+
+	r.ConnectRTT = 12.5
+	r.StatusRTT = 40.25
+	r.SetError(crawl.ProbeStageNetInfo, context.DeadlineExceeded)
+	require.Equal(t, crawl.ErrorClassTimeout, r.ErrorClass)
+
+	bz, err := r.Marshal()
+	require.NoError(t, err)
+
+	other := new(crawl.ProbeResult)
+	require.NoError(t, other.Unmarshal(bz))
+	require.Equal(t, r, *other)
+}
+
+func TestGetProbeHistory(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	id := "5a8a6061c8a2e2e02d497060d5325b6588051cc6"
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for i := 10; i > 0; i-- {
+		ts := now.Add(-time.Duration(i) * time.Hour)
+		bz, err := crawl.NewProbeResult(ts, time.Second, i%2 == 0).Marshal()
+		require.NoError(t, err)
+		require.NoError(t, bdb.Set(crawl.ProbeKey(id, ts), bz))
+	}
+
+	// probes of other nodes must not be included
+	bz, err := crawl.NewProbeResult(now, time.Second, true).Marshal()
+	require.NoError(t, err)
+	require.NoError(t, bdb.Set(crawl.ProbeKey("2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", now), bz))
+
+	history, err := crawl.GetProbeHistory(bdb, id, now.Add(-5*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+
+	for i := 1; i < len(history); i++ {
+		require.True(t, history[i-1].Time().Before(history[i].Time()))
+	}
+
+	history, err = crawl.GetProbeHistory(bdb, id, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, history, 10)
+}
+
+func TestUpdateProbeStats(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	ts := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+
+	probe := func(ago time.Duration, success bool) crawl.ProbeResult {
+		return crawl.NewProbeResult(now.Add(-ago), time.Second, success)
+	}
+
+	testCases := []struct {
+		name        string
+		node        crawl.Node
+		history     []crawl.ProbeResult
+		expFailures uint
+		expFirst    string
+		expLast     string
+		expUptime   crawl.Uptime
+	}{
+		{
+			"empty history",
+			crawl.Node{FirstSeen: ts(72 * time.Hour), LastSeen: ts(48 * time.Hour), ConsecutiveFailures: 5},
+			[]crawl.ProbeResult{},
+			0, ts(72 * time.Hour), ts(48 * time.Hour), crawl.Uptime{},
+		},
+		{
+			"probes outside of uptime windows",
+			crawl.Node{},
+			[]crawl.ProbeResult{probe(10*24*time.Hour, true), probe(3*24*time.Hour, false)},
+			1, ts(10 * 24 * time.Hour), ts(10 * 24 * time.Hour), crawl.Uptime{Day: 0, Week: 0, Month: 50},
+		},
+		{
+			"mixed successes and failures",
+			crawl.Node{},
+			[]crawl.ProbeResult{
+				probe(48*time.Hour, true),
+				probe(3*time.Hour, false),
+				probe(2*time.Hour, true),
+				probe(time.Hour, false),
+				probe(30*time.Minute, false),
+			},
+			2, ts(48 * time.Hour), ts(2 * time.Hour), crawl.Uptime{Day: 25, Week: 40, Month: 40},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			node := tc.node
+			crawl.UpdateProbeStats(&node, tc.history, now)
+
+			require.Equal(t, tc.expFailures, node.ConsecutiveFailures)
+			require.Equal(t, tc.expFirst, node.FirstSeen)
+			require.Equal(t, tc.expLast, node.LastSeen)
+			require.Equal(t, tc.expUptime, node.Uptime)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+)
+
+type (
+	// ProbeTarget defines the addresses of a crawled node handed to a Prober
+	// along with the Dialer by which the prober must connect to the node. The
+	// RPC address is empty if the node's RPC could not be queried.
+	ProbeTarget struct {
+		ID         string
+		Network    string
+		Host       string
+		P2PAddress string
+		RPCAddress string
+		Dialer     Dialer
+	}
+
+	// Metadata defines an extensible set of node metadata collected by probers.
+	// Values must be serializable as both MessagePack and JSON.
+	Metadata map[string]interface{}
+
+	// Prober defines an interface for collecting additional metadata of crawled
+	// nodes. Probers are run for every node that is successfully crawled after
+	// its NodeInfo, status and net info have been recorded. A prober may set the
+	// node's typed fields or add its findings to the node's Metadata. Every
+	// Crawler runs its built-in probers, which geolocate the node and query its
+	// ABCI info and genesis hash, before the registered ones.
+	Prober interface {
+		// Name returns the unique name of the prober under which it is
+		// configured.
+		Name() string
+
+		// Probe probes a node at the given target and records its findings on
+		// the node. An error is returned if the node cannot be probed.
+		Probe(ctx context.Context, target ProbeTarget, node *Node) error
+	}
+
+	// ProberFactory defines a constructor of a Prober from its configuration.
+	ProberFactory func(cfg config.ProberConfig) (Prober, error)
+)
+
+// Names of the built-in probers
+const (
+	GeolocationProberName = "geolocation"
+	ABCIInfoProberName    = "abci_info"
+	GenesisProberName     = "genesis"
+)
+
+var (
+	proberFactoriesMtx sync.RWMutex
+	proberFactories    = make(map[string]ProberFactory)
+)
+
+// Set sets a metadata field.
+func (md *Metadata) Set(key string, value interface{}) {
+	if *md == nil {
+		*md = make(Metadata)
+	}
+
+	(*md)[key] = value
+}
+
+// dialer returns the Dialer of the target, which connects directly if unset.
+func (t ProbeTarget) dialer() Dialer {
+	if t.Dialer == nil {
+		return &net.Dialer{}
+	}
+
+	return t.Dialer
+}
+
+// RegisterProberFactory registers a ProberFactory under the name by which its
+// prober is configured in the [probers] section of the config. It panics if a
+// factory is registered twice under the same name.
+func RegisterProberFactory(name string, factory ProberFactory) {
+	proberFactoriesMtx.Lock()
+	defer proberFactoriesMtx.Unlock()
+
+	if _, ok := proberFactories[name]; ok {
+		panic(fmt.Sprintf("prober factory already registered: %s", name))
+	}
+
+	proberFactories[name] = factory
+}
+
+// NewProbers returns all enabled probers of the config ordered by name. An
+// error is returned if an enabled prober is unknown or cannot be constructed.
+func NewProbers(cfg config.Config) ([]Prober, error) {
+	proberFactoriesMtx.RLock()
+	defer proberFactoriesMtx.RUnlock()
+
+	names := make([]string, 0, len(cfg.Probers))
+	for name, pcfg := range cfg.Probers {
+		if pcfg.Enabled() {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	probers := make([]Prober, len(names))
+
+	for i, name := range names {
+		factory, ok := proberFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown prober: %s", name)
+		}
+
+		p, err := factory(cfg.Probers[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create prober %s: %w", name, err)
+		}
+
+		probers[i] = p
+	}
+
+	return probers, nil
+}
+
+// builtinProbers returns the probers every Crawler runs for its crawled nodes.
+func builtinProbers(c *Crawler) []Prober {
+	return []Prober{geolocationProber{c: c}, abciInfoProber{}, genesisProber{c: c}}
+}
+
+// RegisterProber registers a Prober that is run for every crawled node.
+func (c *Crawler) RegisterProber(p Prober) {
+	c.probers = append(c.probers, p)
+}
+
+// geolocationProber implements a Prober which records the geolocation of a
+// node's host.
+type geolocationProber struct {
+	c *Crawler
+}
+
+func (geolocationProber) Name() string {
+	return GeolocationProberName
+}
+
+func (p geolocationProber) Probe(_ context.Context, target ProbeTarget, node *Node) error {
+	loc, err := p.c.GetGeolocation(target.Host)
+	if err != nil {
+		return err
+	}
+
+	node.Location = loc
+	return nil
+}
+
+// abciInfoProber implements a Prober which records the ABCI application info
+// of a node with a reachable RPC.
+type abciInfoProber struct{}
+
+func (abciInfoProber) Name() string {
+	return ABCIInfoProberName
+}
+
+func (abciInfoProber) Probe(ctx context.Context, target ProbeTarget, node *Node) error {
+	if target.RPCAddress == "" {
+		return nil
+	}
+
+	res, err := newRPCClient(ctx, target.dialer(), target.RPCAddress).ABCIInfo()
+	if err != nil {
+		return err
+	}
+
+	node.AppInfo = appInfoFromResult(res)
+	return nil
+}
+
+// genesisProber implements a Prober which records the genesis hash of the
+// network of a node with a reachable RPC.
+type genesisProber struct {
+	c *Crawler
+}
+
+func (genesisProber) Name() string {
+	return GenesisProberName
+}
+
+func (p genesisProber) Probe(ctx context.Context, target ProbeTarget, node *Node) error {
+	if target.RPCAddress == "" {
+		return nil
+	}
+
+	hash, err := p.c.GetGenesisHash(ctx, target.Network, target.RPCAddress)
+	if err != nil {
+		return err
+	}
+
+	node.GenesisHash = hash
+	return nil
+}
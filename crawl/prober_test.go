@@ -0,0 +1,64 @@
+package crawl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+)
+
+type testProber struct {
+	key string
+}
+
+func (p testProber) Name() string {
+	return "test"
+}
+
+func (p testProber) Probe(_ context.Context, target crawl.ProbeTarget, node *crawl.Node) error {
+	node.Metadata.Set(p.key, target.Host)
+	return nil
+}
+
+func init() {
+	crawl.RegisterProberFactory("test", func(pcfg config.ProberConfig) (crawl.Prober, error) {
+		cfg := struct {
+			Key string `toml:"key"`
+		}{}
+
+		if err := pcfg.Decode(&cfg); err != nil {
+			return nil, err
+		}
+
+		return testProber{key: cfg.Key}, nil
+	})
+}
+
+func TestNewProbers(t *testing.T) {
+	probers, err := crawl.NewProbers(config.Config{
+		Probers: map[string]config.ProberConfig{
+			"test":                   {"enabled": true, "key": "host"},
+			crawl.ServicesProberName: {"enabled": true, "rest_port": int64(1318)},
+			crawl.RPCAuditProberName: {"enabled": false},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, probers, 2)
+	require.Equal(t, crawl.ServicesProberName, probers[0].Name())
+	require.Equal(t, "test", probers[1].Name())
+
+	node := crawl.Node{}
+	require.NoError(t, probers[1].Probe(context.Background(), crawl.ProbeTarget{Host: "127.0.0.1"}, &node))
+	require.Equal(t, crawl.Metadata{"host": "127.0.0.1"}, node.Metadata)
+
+	_, err = crawl.NewProbers(config.Config{
+		Probers: map[string]config.ProberConfig{"unknown": {"enabled": true}},
+	})
+	require.Error(t, err)
+
+	require.Panics(t, func() {
+		crawl.RegisterProberFactory("test", nil)
+	})
+}
@@ -0,0 +1,264 @@
+package crawl
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// crawlRunHistoryWindow defines how long crawl runs are retained for.
+const crawlRunHistoryWindow = 30 * 24 * time.Hour
+
+// CrawlRunKeyPrefix defines the persistence prefix key of crawl runs.
+var CrawlRunKeyPrefix = []byte("crawl/")
+
+// ErrCrawlRunNotFound defines a sentinel error for when a crawl run cannot be
+// found by its ID.
+var ErrCrawlRunNotFound = errors.New("crawl run not found")
+
+type (
+	// CrawlRun defines the statistics of a single crawl round, i.e. the crawl of
+	// the node pool from a (re)seed until the pool is exhausted. Attempted counts
+	// every node whose crawl was started. A completed crawl either succeeded,
+	// failed or was skipped as the node belongs to another network. Discovered
+	// counts the nodes that were persisted for the first time and Purged the
+	// offline nodes that were removed. Errors counts the failed steps of node
+	// crawls by stage. The end time of a run that is still in progress is empty.
+	CrawlRun struct {
+		ID         string         `json:"id" yaml:"id"`
+		Network    string         `json:"network" yaml:"network"`
+		StartTime  string         `json:"start_time" yaml:"start_time"`
+		EndTime    string         `json:"end_time" yaml:"end_time"`
+		Attempted  uint           `json:"attempted" yaml:"attempted"`
+		Succeeded  uint           `json:"succeeded" yaml:"succeeded"`
+		Failed     uint           `json:"failed" yaml:"failed"`
+		Skipped    uint           `json:"skipped" yaml:"skipped"`
+		Discovered uint           `json:"discovered" yaml:"discovered"`
+		Purged     uint           `json:"purged" yaml:"purged"`
+		Errors     map[string]int `json:"errors" yaml:"errors"`
+	}
+
+	// crawlRunTracker tracks the statistics of the current crawl run which are
+	// updated concurrently by the crawl workers.
+	crawlRunTracker struct {
+		mtx sync.Mutex
+		run *CrawlRun
+	}
+)
+
+// NewCrawlRun returns a CrawlRun of a network started at the given time. Its ID
+// is derived from its start time.
+func NewCrawlRun(network string, start time.Time) CrawlRun {
+	return CrawlRun{
+		ID:        strconv.FormatInt(start.UnixNano(), 10),
+		Network:   network,
+		StartTime: start.UTC().Format(time.RFC3339),
+		Errors:    make(map[string]int),
+	}
+}
+
+// Marshal returns the MessagePack encoding of a CrawlRun.
+func (r CrawlRun) Marshal() ([]byte, error) {
+	bz, err := msgpack.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return bz, nil
+}
+
+// Unmarshal unmarshals a MessagePack encoding of a CrawlRun.
+func (r *CrawlRun) Unmarshal(bz []byte) error {
+	if err := msgpack.Unmarshal(bz, r); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CrawlRunKey constructs the DB key of a crawl run by its ID. Keys are ordered
+// by the start time of the run. Nil is returned for an invalid ID.
+func CrawlRunKey(id string) []byte {
+	ts, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, ts)
+
+	return append(append([]byte{}, CrawlRunKeyPrefix...), bz...)
+}
+
+// GetCrawlRun returns a persisted crawl run by its ID. ErrCrawlRunNotFound is
+// returned if no crawl run can be found.
+func GetCrawlRun(db db.DB, id string) (CrawlRun, error) {
+	key := CrawlRunKey(id)
+	if key == nil || !db.Has(key) {
+		return CrawlRun{}, ErrCrawlRunNotFound
+	}
+
+	bz, err := db.Get(key)
+	if err != nil {
+		return CrawlRun{}, err
+	}
+
+	run := new(CrawlRun)
+	if err := run.Unmarshal(bz); err != nil {
+		return CrawlRun{}, err
+	}
+
+	return *run, nil
+}
+
+// GetCrawlRuns returns up to limit persisted crawl runs ordered from newest to
+// oldest. If a cursor, i.e. the ID of a crawl run, is given, only crawl runs
+// started before it are returned. All remaining crawl runs are returned if the
+// limit is zero. An error is returned if the cursor is invalid or a crawl run
+// cannot be decoded.
+func GetCrawlRuns(db db.DB, cursor string, limit int) ([]CrawlRun, error) {
+	var end []byte
+	if cursor != "" {
+		if end = CrawlRunKey(cursor); end == nil {
+			return nil, fmt.Errorf("invalid crawl run cursor: %s", cursor)
+		}
+	}
+
+	runs := []CrawlRun{}
+
+	var err error
+	db.ReverseIteratePrefix(CrawlRunKeyPrefix, end, func(_, v []byte) bool {
+		run := new(CrawlRun)
+
+		err = run.Unmarshal(v)
+		if err != nil {
+			return true
+		}
+
+		runs = append(runs, *run)
+		return len(runs) == limit
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// update applies a change to the current crawl run, if any.
+func (t *crawlRunTracker) update(f func(r *CrawlRun)) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.run != nil {
+		f(t.run)
+	}
+}
+
+// start starts tracking a new crawl run.
+func (t *crawlRunTracker) start(run CrawlRun) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.run = &run
+}
+
+// finish stops tracking the current crawl run and returns it with its end time
+// set. False is returned if no crawl run is being tracked.
+func (t *crawlRunTracker) finish(end time.Time) (CrawlRun, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.run == nil {
+		return CrawlRun{}, false
+	}
+
+	run := *t.run
+	run.EndTime = end.UTC().Format(time.RFC3339)
+	t.run = nil
+
+	return run, true
+}
+
+// startCrawlRun starts a new crawl run. The run is persisted once its first
+// node crawl is attempted, so rounds in which no node is crawled are not
+// recorded.
+func (c *Crawler) startCrawlRun(now time.Time) {
+	c.runs.start(NewCrawlRun(c.network, now))
+}
+
+// attemptCrawlRun counts an attempted node crawl of the current crawl run and
+// persists the run on its first attempt.
+func (c *Crawler) attemptCrawlRun() {
+	c.runs.update(func(r *CrawlRun) {
+		r.Attempted++
+		if r.Attempted > 1 {
+			return
+		}
+
+		if err := c.saveCrawlRun(*r); err != nil {
+			log.Info().Err(err).Str("network", c.network).Str("crawl_id", r.ID).Msg("failed to persist crawl run")
+		}
+	})
+}
+
+// finishCrawlRun persists the statistics of the current crawl run and prunes
+// crawl runs that fall outside of the history window. Crawl runs in which no
+// node was attempted are discarded.
+func (c *Crawler) finishCrawlRun(now time.Time) {
+	run, ok := c.runs.finish(now)
+	if !ok || run.Attempted == 0 {
+		return
+	}
+
+	log.Info().Str("network", c.network).Str("crawl_id", run.ID).Uint("attempted", run.Attempted).Uint("succeeded", run.Succeeded).Uint("failed", run.Failed).Uint("discovered", run.Discovered).Uint("purged", run.Purged).Msg("finished crawl run")
+
+	if err := c.saveCrawlRun(run); err != nil {
+		log.Info().Err(err).Str("network", c.network).Str("crawl_id", run.ID).Msg("failed to persist crawl run")
+	}
+
+	if err := c.pruneCrawlRuns(now.Add(-crawlRunHistoryWindow)); err != nil {
+		log.Info().Err(err).Str("network", c.network).Msg("failed to prune crawl runs")
+	}
+}
+
+// saveCrawlRun persists a crawl run.
+func (c *Crawler) saveCrawlRun(run CrawlRun) error {
+	bz, err := run.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return c.db.Set(CrawlRunKey(run.ID), bz)
+}
+
+// pruneCrawlRuns removes all crawl runs started before the given time.
+func (c *Crawler) pruneCrawlRuns(before time.Time) error {
+	end := CrawlRunKey(strconv.FormatInt(before.UnixNano(), 10))
+	keys := [][]byte{}
+
+	c.db.IteratePrefix(CrawlRunKeyPrefix, func(k, _ []byte) bool {
+		if string(k) >= string(end) {
+			return true
+		}
+
+		keys = append(keys, append([]byte{}, k...))
+		return false
+	})
+
+	for _, k := range keys {
+		if err := c.db.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
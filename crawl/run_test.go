@@ -0,0 +1,111 @@
+package crawl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlRun_Serialize(t *testing.T) {
+	run := crawl.NewCrawlRun("cosmoshub-3", time.Now())
+	run.EndTime = time.Now().UTC().Format(time.RFC3339)
+	run.Attempted = 10
+	run.Succeeded = 7
+	run.Failed = 2
+	run.Skipped = 1
+	run.Discovered = 3
+	run.Purged = 1
+	run.Errors[crawl.ProbeStageP2P] = 2
+
+	bz, err := run.Marshal()
+	require.NoError(t, err)
+
+	other := new(crawl.CrawlRun)
+	require.NoError(t, other.Unmarshal(bz))
+	require.Equal(t, run, *other)
+}
+
+func TestGetCrawlRuns(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+
+	for i := 3; i > 0; i-- {
+		run := crawl.NewCrawlRun("cosmoshub-3", now.Add(-time.Duration(i)*time.Hour))
+
+		bz, err := run.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, bdb.Set(crawl.CrawlRunKey(run.ID), bz))
+	}
+
+	runs, err := crawl.GetCrawlRuns(bdb, "", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+
+	for i := 1; i < len(runs); i++ {
+		require.True(t, runs[i-1].ID > runs[i].ID)
+	}
+
+	// runs are paginated from the newest run before the cursor
+	page, err := crawl.GetCrawlRuns(bdb, "", 2)
+	require.NoError(t, err)
+	require.Equal(t, runs[:2], page)
+
+	page, err = crawl.GetCrawlRuns(bdb, page[1].ID, 2)
+	require.NoError(t, err)
+	require.Equal(t, runs[2:], page)
+
+	_, err = crawl.GetCrawlRuns(bdb, "invalid", 2)
+	require.Error(t, err)
+
+	run, err := crawl.GetCrawlRun(bdb, runs[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, runs[1], run)
+
+	_, err = crawl.GetCrawlRun(bdb, "1")
+	require.Equal(t, crawl.ErrCrawlRunNotFound, err)
+
+	_, err = crawl.GetCrawlRun(bdb, "invalid")
+	require.Equal(t, crawl.ErrCrawlRunNotFound, err)
+}
+
+func TestCrawler_CrawlRuns(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{ChainID: "cosmoshub-3"}, bdb)
+	ndb := crawl.NetworkDB(bdb, "cosmoshub-3")
+	now := time.Now().UTC()
+
+	// a round in which no node was attempted is not recorded
+	c.StartCrawlRun(now)
+	c.FinishCrawlRun(now.Add(time.Second))
+
+	runs, err := crawl.GetCrawlRuns(ndb, "", 0)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+
+	// a round is recorded in progress once its first node is attempted
+	c.StartCrawlRun(now.Add(time.Minute))
+	c.AttemptCrawlRun()
+
+	runs, err = crawl.GetCrawlRuns(ndb, "", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, uint(1), runs[0].Attempted)
+	require.Empty(t, runs[0].EndTime)
+
+	c.AttemptCrawlRun()
+	c.FinishCrawlRun(now.Add(2 * time.Minute))
+
+	runs, err = crawl.GetCrawlRuns(ndb, "", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, uint(2), runs[0].Attempted)
+	require.NotEmpty(t, runs[0].EndTime)
+}
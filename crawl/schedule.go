@@ -0,0 +1,184 @@
+package crawl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/tendermint/tendermint/p2p"
+)
+
+// Recheck interval multipliers applied to the base recheck interval.
+const (
+	// recheckBackoffCap caps the exponential backoff multiplier of offline nodes.
+	recheckBackoffCap = 32
+	// recheckHealthyFactor defines the multiplier of healthy nodes.
+	recheckHealthyFactor = 4
+	// recheckUnstableDivisor defines the divisor of new and flapping nodes.
+	recheckUnstableDivisor = 2
+
+	// recheckNewPeriod defines how long after it was first seen a node is
+	// considered new.
+	recheckNewPeriod = 24 * time.Hour
+	// recheckHealthyUptime defines the minimum 7d uptime of a healthy node.
+	recheckHealthyUptime = 99
+	// recheckFlappingUptime defines the 24h uptime below which an online node is
+	// considered to be flapping.
+	recheckFlappingUptime = 90
+)
+
+// Schedule persistence prefix keys
+var (
+	ScheduleKeyPrefix    = []byte("schedule/")
+	ScheduleRefKeyPrefix = []byte("schedule_ref/")
+)
+
+// ScheduleKey constructs the DB key of a node's scheduled recheck. Keys are
+// ordered by the time of the recheck, so iterating over ScheduleKeyPrefix
+// yields nodes in the order they are due.
+func ScheduleKey(id string, t time.Time) []byte {
+	ts := make([]byte, 8)
+	binary.BigEndian.PutUint64(ts, uint64(t.UnixNano()))
+
+	return append(append(ScheduleKeyPrefix, ts...), []byte(id)...)
+}
+
+// ScheduleRefKey constructs the DB key which refers to the current scheduled
+// recheck key of a node.
+func ScheduleRefKey(id string) []byte {
+	return append(ScheduleRefKeyPrefix, []byte(id)...)
+}
+
+// nextRecheckInterval returns the adaptive interval after which a node should
+// be rechecked given the base recheck interval. Offline nodes are backed off
+// exponentially by their number of consecutive failures. New, failing and
+// flapping nodes are checked more often and healthy nodes less often.
+func nextRecheckInterval(n Node, base time.Duration, offlineFailures uint, now time.Time) time.Duration {
+	if n.Status == NodeStatusOffline {
+		multiplier := uint64(1)
+		for i := offlineFailures; i < n.ConsecutiveFailures && multiplier < recheckBackoffCap; i++ {
+			multiplier *= 2
+		}
+
+		return base * time.Duration(multiplier)
+	}
+
+	firstSeen, _ := time.Parse(time.RFC3339, n.FirstSeen)
+
+	switch {
+	case n.ConsecutiveFailures > 0, now.Sub(firstSeen) < recheckNewPeriod, n.Uptime.Day < recheckFlappingUptime:
+		return base / recheckUnstableDivisor
+
+	case n.Uptime.Week >= recheckHealthyUptime:
+		return base * recheckHealthyFactor
+
+	default:
+		return base
+	}
+}
+
+// ScheduleRecheck persists the time at which a node should next be rechecked,
+// replacing any previously scheduled recheck of the node. The node's P2P
+// address is stored with the entry so due nodes can be queued without decoding
+// the node.
+func (c *Crawler) ScheduleRecheck(n Node, t time.Time) error {
+	if err := c.UnscheduleRecheck(n.ID); err != nil {
+		return err
+	}
+
+	key := ScheduleKey(n.ID, t)
+	nodeAddr := p2p.IDAddressString(p2p.ID(n.ID), n.P2PAddress())
+
+	if err := c.db.Set(key, []byte(nodeAddr)); err != nil {
+		return err
+	}
+
+	return c.db.Set(ScheduleRefKey(n.ID), key)
+}
+
+// UnscheduleRecheck removes the scheduled recheck of a node if one exists.
+func (c *Crawler) UnscheduleRecheck(id string) error {
+	refKey := ScheduleRefKey(id)
+	if !c.db.Has(refKey) {
+		return nil
+	}
+
+	key, err := c.db.Get(refKey)
+	if err != nil {
+		return err
+	}
+
+	if err := c.db.Delete(key); err != nil {
+		return err
+	}
+
+	return c.db.Delete(refKey)
+}
+
+// scheduleNextRecheck schedules the next recheck of a node using its adaptive
+// recheck interval.
+func (c *Crawler) scheduleNextRecheck(n Node, now time.Time) error {
+	base := time.Duration(c.recheckInterval) * time.Second
+	return c.ScheduleRecheck(n, now.Add(nextRecheckInterval(n, base, c.offlineFailures, now)))
+}
+
+// GetDueRechecks returns the node IDs and P2P addresses of all nodes whose
+// scheduled recheck is at or before the given time. Only due entries of the
+// schedule are visited.
+func (c *Crawler) GetDueRechecks(t time.Time) map[string]string {
+	due := make(map[string]string)
+	end := ScheduleKey("", t.Add(time.Nanosecond))
+
+	c.db.IteratePrefix(ScheduleKeyPrefix, func(k, v []byte) bool {
+		if bytes.Compare(k, end) >= 0 {
+			return true
+		}
+
+		id := string(k[len(ScheduleKeyPrefix)+8:])
+		due[id] = string(v)
+
+		return false
+	})
+
+	return due
+}
+
+// backfillIndexes schedules an immediate recheck for every persisted node that
+// does not have a scheduled recheck and indexes healthy nodes, e.g. for nodes
+// persisted before the schedule and the healthy node index existed.
+func (c *Crawler) backfillIndexes(now time.Time) error {
+	nodes := []Node{}
+
+	var err error
+	c.db.IteratePrefix(NodeKeyPrefix, func(_, v []byte) bool {
+		node := new(Node)
+
+		err = node.Unmarshal(v)
+		if err != nil {
+			return true
+		}
+
+		nodes = append(nodes, *node)
+		return false
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if err := c.indexHealthy(node); err != nil {
+			return err
+		}
+
+		if c.db.Has(ScheduleRefKey(node.ID)) {
+			continue
+		}
+
+		if err := c.ScheduleRecheck(node, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
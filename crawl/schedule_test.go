@@ -0,0 +1,97 @@
+package crawl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/fissionlabsio/tmcrawl/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawler_ScheduleRecheck(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	c := crawl.NewCrawler(config.Config{MaxConns: 1}, config.NetworkConfig{RecheckInterval: 3600}, bdb)
+	now := time.Now().UTC()
+
+	n1 := crawl.Node{ID: "5a8a6061c8a2e2e02d497060d5325b6588051cc6", Address: "127.0.0.1", P2PPort: "26656"}
+	n2 := crawl.Node{ID: "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", Address: "127.0.0.2", P2PPort: "26656"}
+
+	require.NoError(t, c.ScheduleRecheck(n1, now.Add(-time.Minute)))
+	require.NoError(t, c.ScheduleRecheck(n2, now.Add(time.Hour)))
+
+	due := c.GetDueRechecks(now)
+	require.Equal(t, map[string]string{n1.ID: n1.ID + "@127.0.0.1:26656"}, due)
+
+	// rescheduling replaces the previous entry
+	require.NoError(t, c.ScheduleRecheck(n1, now.Add(time.Hour)))
+	require.Empty(t, c.GetDueRechecks(now))
+	require.Len(t, c.GetDueRechecks(now.Add(time.Hour)), 2)
+
+	require.NoError(t, c.UnscheduleRecheck(n2.ID))
+	require.Len(t, c.GetDueRechecks(now.Add(time.Hour)), 1)
+}
+
+func TestNextRecheckInterval(t *testing.T) {
+	base := time.Hour
+	now := time.Now().UTC()
+	established := now.Add(-7 * 24 * time.Hour).Format(time.RFC3339)
+
+	testCases := []struct {
+		name     string
+		node     crawl.Node
+		expected time.Duration
+	}{
+		{
+			"offline at failure threshold",
+			crawl.Node{Status: crawl.NodeStatusOffline, ConsecutiveFailures: 3},
+			base,
+		},
+		{
+			"offline backed off by failures",
+			crawl.Node{Status: crawl.NodeStatusOffline, ConsecutiveFailures: 5},
+			4 * base,
+		},
+		{
+			"offline backoff capped",
+			crawl.Node{Status: crawl.NodeStatusOffline, ConsecutiveFailures: 100},
+			32 * base,
+		},
+		{
+			"new node",
+			crawl.Node{Status: crawl.NodeStatusOnline, FirstSeen: now.Add(-time.Hour).Format(time.RFC3339), Uptime: crawl.Uptime{Day: 100, Week: 100}},
+			base / 2,
+		},
+		{
+			"failing node",
+			crawl.Node{Status: crawl.NodeStatusOnline, FirstSeen: established, ConsecutiveFailures: 1, Uptime: crawl.Uptime{Day: 100, Week: 100}},
+			base / 2,
+		},
+		{
+			"flapping node",
+			crawl.Node{Status: crawl.NodeStatusOnline, FirstSeen: established, Uptime: crawl.Uptime{Day: 80, Week: 99}},
+			base / 2,
+		},
+		{
+			"healthy node",
+			crawl.Node{Status: crawl.NodeStatusOnline, FirstSeen: established, Uptime: crawl.Uptime{Day: 100, Week: 99.5}},
+			4 * base,
+		},
+		{
+			"stable node",
+			crawl.Node{Status: crawl.NodeStatusOnline, FirstSeen: established, Uptime: crawl.Uptime{Day: 95, Week: 95}},
+			base,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, crawl.NextRecheckInterval(tc.node, base, 3, now))
+		})
+	}
+}
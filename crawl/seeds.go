@@ -0,0 +1,237 @@
+package crawl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/rs/zerolog/log"
+	"github.com/tendermint/tendermint/p2p"
+)
+
+type (
+	// ChainRegistry defines the sections of a Cosmos chain-registry chain.json
+	// file which contain the peers and public API endpoints of a chain.
+	ChainRegistry struct {
+		ChainID string `json:"chain_id"`
+		Peers   struct {
+			Seeds           []ChainRegistryPeer `json:"seeds"`
+			PersistentPeers []ChainRegistryPeer `json:"persistent_peers"`
+		} `json:"peers"`
+		APIs struct {
+			RPC []ChainRegistryAPI `json:"rpc"`
+		} `json:"apis"`
+	}
+
+	// ChainRegistryPeer defines a peer listed in a chain-registry file.
+	ChainRegistryPeer struct {
+		ID       string `json:"id"`
+		Address  string `json:"address"`
+		Provider string `json:"provider,omitempty"`
+	}
+
+	// ChainRegistryAPI defines an API endpoint listed in a chain-registry file.
+	ChainRegistryAPI struct {
+		Address  string `json:"address"`
+		Provider string `json:"provider,omitempty"`
+	}
+)
+
+// ParseChainRegistry parses a Cosmos chain-registry chain.json file.
+func ParseChainRegistry(bz []byte) (ChainRegistry, error) {
+	var reg ChainRegistry
+	if err := json.Unmarshal(bz, &reg); err != nil {
+		return ChainRegistry{}, fmt.Errorf("failed to decode chain registry: %w", err)
+	}
+
+	return reg, nil
+}
+
+// Seeds returns the seeds and persistent peers of the chain registry in the
+// form of id@host:port followed by its RPC endpoints. Invalid peers and
+// endpoints are logged and skipped. An error is returned if none of them is
+// valid.
+func (r ChainRegistry) Seeds() ([]string, error) {
+	seeds := []string{}
+
+	for _, peers := range [][]ChainRegistryPeer{r.Peers.Seeds, r.Peers.PersistentPeers} {
+		for _, p := range peers {
+			peer, err := parsePeer(fmt.Sprintf("%s@%s", p.ID, p.Address))
+			if err != nil {
+				log.Info().Err(err).Str("chain_id", r.ChainID).Msg("skipping invalid chain registry peer")
+				continue
+			}
+
+			seeds = append(seeds, peer)
+		}
+	}
+
+	for _, api := range r.APIs.RPC {
+		endpoint, err := parseRPCEndpoint(api.Address)
+		if err != nil {
+			log.Info().Err(err).Str("chain_id", r.ChainID).Msg("skipping invalid chain registry RPC endpoint")
+			continue
+		}
+
+		seeds = append(seeds, endpoint)
+	}
+
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("chain registry of network %s has no valid peers or RPC endpoints", r.ChainID)
+	}
+
+	return seeds, nil
+}
+
+// ParsePeers parses a comma-separated list of peers in the form of
+// id@host:port, e.g. the persistent_peers or seeds option of a Tendermint
+// config. An error is returned if any of the peers is invalid.
+func ParsePeers(s string) ([]string, error) {
+	peers := []string{}
+
+	for _, peer := range strings.Split(s, ",") {
+		peer = strings.TrimSpace(peer)
+		if peer == "" {
+			continue
+		}
+
+		peer, err := parsePeer(peer)
+		if err != nil {
+			return nil, err
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+// LoadSeeds returns the seeds of a network profile along with all seeds
+// imported from its seed sources, without duplicates. An error is returned if
+// a seed source cannot be read or parsed or if a chain-registry file belongs to
+// a different network.
+func LoadSeeds(netCfg config.NetworkConfig) ([]string, error) {
+	seeds := append([]string{}, netCfg.Seeds...)
+
+	peers, err := ParsePeers(netCfg.Peers)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds = append(seeds, peers...)
+
+	for _, path := range netCfg.AddrBooks {
+		bz, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read address book: %w", err)
+		}
+
+		book, err := ParseAddrBook(bz)
+		if err != nil {
+			return nil, err
+		}
+
+		seeds = append(seeds, book.Seeds()...)
+	}
+
+	for _, path := range netCfg.ChainRegistries {
+		bz, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chain registry: %w", err)
+		}
+
+		reg, err := ParseChainRegistry(bz)
+		if err != nil {
+			return nil, err
+		}
+
+		if netCfg.ChainID != "" && reg.ChainID != netCfg.ChainID {
+			return nil, fmt.Errorf("chain registry %s belongs to network %s", path, reg.ChainID)
+		}
+
+		regSeeds, err := reg.Seeds()
+		if err != nil {
+			return nil, err
+		}
+
+		seeds = append(seeds, regSeeds...)
+	}
+
+	return dedupSeeds(seeds), nil
+}
+
+// parsePeer validates a peer address in the form of id@host:port and returns
+// it without a protocol prefix.
+func parsePeer(peer string) (string, error) {
+	if i := strings.Index(peer, "://"); i >= 0 {
+		peer = peer[i+3:]
+	}
+
+	i := strings.Index(peer, "@")
+	if i < 0 {
+		return "", fmt.Errorf("invalid peer address %s: missing node ID", peer)
+	}
+
+	id, addr := peer[:i], peer[i+1:]
+
+	if bz, err := hex.DecodeString(id); err != nil || len(bz) != p2p.IDByteLength {
+		return "", fmt.Errorf("invalid peer address %s: invalid node ID", peer)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return "", fmt.Errorf("invalid peer address %s: invalid host or port", peer)
+	}
+
+	if x, err := strconv.ParseUint(port, 10, 16); err != nil || x == 0 {
+		return "", fmt.Errorf("invalid peer address %s: invalid port", peer)
+	}
+
+	return p2p.IDAddressString(p2p.ID(strings.ToLower(id)), net.JoinHostPort(host, port)), nil
+}
+
+// parseRPCEndpoint validates an RPC endpoint URL and returns it with an
+// explicit port, using the scheme's default port if it has none.
+func parseRPCEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid RPC endpoint: %s", endpoint)
+	}
+
+	port := u.Port()
+
+	switch {
+	case port != "":
+	case u.Scheme == "https":
+		port = "443"
+	case u.Scheme == "http":
+		port = "80"
+	default:
+		return "", fmt.Errorf("invalid RPC endpoint: %s", endpoint)
+	}
+
+	return fmt.Sprintf("%s://%s", u.Scheme, net.JoinHostPort(u.Hostname(), port)), nil
+}
+
+// dedupSeeds returns the seeds without duplicates in their original order.
+func dedupSeeds(seeds []string) []string {
+	seen := make(map[string]struct{}, len(seeds))
+	unique := make([]string, 0, len(seeds))
+
+	for _, s := range seeds {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+
+		seen[s] = struct{}{}
+		unique = append(unique, s)
+	}
+
+	return unique
+}
@@ -0,0 +1,164 @@
+package crawl_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAddrBook = `{
+	"key": "5c5f9b2a0d1e3f4a6b7c8d9e",
+	"addrs": [
+		{
+			"addr": {"id": "5a8a6061c8a2e2e02d497060d5325b6588051cc6", "ip": "1.2.3.4", "port": 26656},
+			"src": {"id": "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", "ip": "5.6.7.8", "port": 26656},
+			"buckets": [12],
+			"attempts": 0,
+			"bucket_type": 1,
+			"last_attempt": "2020-01-20T00:00:00Z",
+			"last_success": "0001-01-01T00:00:00Z"
+		},
+		{
+			"addr": {"id": "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", "ip": "5.6.7.8", "port": 26656},
+			"src": {"id": "2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b", "ip": "5.6.7.8", "port": 26656},
+			"buckets": [3],
+			"attempts": 0,
+			"bucket_type": 2,
+			"last_attempt": "2020-01-20T00:00:00Z",
+			"last_success": "2020-01-20T00:00:00Z"
+		}
+	]
+}`
+
+	testChainRegistry = `{
+	"chain_name": "cosmoshub",
+	"chain_id": "cosmoshub-3",
+	"peers": {
+		"seeds": [
+			{"id": "ba3bacc714817218562f743178228f23678b2873", "address": "public-seed-node.cosmoshub.certus.one:26656", "provider": "Certus One"}
+		],
+		"persistent_peers": [
+			{"id": "5a8a6061c8a2e2e02d497060d5325b6588051cc6", "address": "1.2.3.4:26656"}
+		]
+	},
+	"apis": {
+		"rpc": [
+			{"address": "https://rpc.cosmos.network", "provider": "cosmos"},
+			{"address": "http://1.2.3.4:26657"}
+		]
+	}
+}`
+)
+
+func writeTempFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	return path
+}
+
+func TestParsePeers(t *testing.T) {
+	peers, err := crawl.ParsePeers(" 5A8A6061C8A2E2E02D497060D5325B6588051CC6@1.2.3.4:26656, tcp://2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b@seed.example.com:26656,")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656",
+		"2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b@seed.example.com:26656",
+	}, peers)
+
+	peers, err = crawl.ParsePeers("")
+	require.NoError(t, err)
+	require.Empty(t, peers)
+
+	for _, invalid := range []string{
+		"1.2.3.4:26656",
+		"invalid@1.2.3.4:26656",
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4",
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:0",
+	} {
+		_, err := crawl.ParsePeers(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParseAddrBook(t *testing.T) {
+	book, err := crawl.ParseAddrBook([]byte(testAddrBook))
+	require.NoError(t, err)
+	require.Len(t, book.Addrs, 2)
+	require.Equal(t, []int{12}, book.Addrs[0].Buckets)
+	require.Equal(t, []string{
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656",
+		"2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b@5.6.7.8:26656",
+	}, book.Seeds())
+
+	_, err = crawl.ParseAddrBook([]byte("invalid"))
+	require.Error(t, err)
+}
+
+func TestChainRegistry_Seeds(t *testing.T) {
+	reg, err := crawl.ParseChainRegistry([]byte(testChainRegistry))
+	require.NoError(t, err)
+	require.Equal(t, "cosmoshub-3", reg.ChainID)
+
+	seeds, err := reg.Seeds()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"ba3bacc714817218562f743178228f23678b2873@public-seed-node.cosmoshub.certus.one:26656",
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656",
+		"https://rpc.cosmos.network:443",
+		"http://1.2.3.4:26657",
+	}, seeds)
+
+	// invalid entries are skipped
+	reg.Peers.Seeds = append(reg.Peers.Seeds, crawl.ChainRegistryPeer{ID: "invalid", Address: "5.6.7.8:26656"})
+	reg.APIs.RPC = append(reg.APIs.RPC, crawl.ChainRegistryAPI{Address: "invalid"})
+
+	other, err := reg.Seeds()
+	require.NoError(t, err)
+	require.Equal(t, seeds, other)
+
+	// a chain registry without any valid entry cannot be imported
+	reg.Peers.Seeds = []crawl.ChainRegistryPeer{{ID: "invalid", Address: "5.6.7.8:26656"}}
+	reg.Peers.PersistentPeers = nil
+	reg.APIs.RPC = nil
+
+	_, err = reg.Seeds()
+	require.Error(t, err)
+}
+
+func TestLoadSeeds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmcrawl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	addrBook := writeTempFile(t, dir, "addrbook.json", testAddrBook)
+	chainRegistry := writeTempFile(t, dir, "chain.json", testChainRegistry)
+
+	seeds, err := crawl.LoadSeeds(config.NetworkConfig{
+		ChainID:         "cosmoshub-3",
+		Seeds:           []string{"http://1.2.3.4:26657"},
+		Peers:           "5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656",
+		AddrBooks:       []string{addrBook},
+		ChainRegistries: []string{chainRegistry},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"http://1.2.3.4:26657",
+		"5a8a6061c8a2e2e02d497060d5325b6588051cc6@1.2.3.4:26656",
+		"2b3e4d3a7d6e4d44e3a2f0d2e7ba5e1b1c2f3a4b@5.6.7.8:26656",
+		"ba3bacc714817218562f743178228f23678b2873@public-seed-node.cosmoshub.certus.one:26656",
+		"https://rpc.cosmos.network:443",
+	}, seeds)
+
+	// chain-registry files of other networks must not be imported
+	_, err = crawl.LoadSeeds(config.NetworkConfig{ChainID: "chain-0", ChainRegistries: []string{chainRegistry}})
+	require.Error(t, err)
+
+	_, err = crawl.LoadSeeds(config.NetworkConfig{AddrBooks: []string{filepath.Join(dir, "missing.json")}})
+	require.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/fissionlabsio/tmcrawl/config"
+	"golang.org/x/net/http2"
+)
+
+// Companion services of a Tendermint node
+const (
+	ServiceREST       = "rest"
+	ServiceGRPC       = "grpc"
+	ServiceGRPCWeb    = "grpc_web"
+	ServicePrometheus = "prometheus"
+)
+
+// ServicesProberName defines the name of the service discovery prober.
+const ServicesProberName = "services"
+
+// Default ports of companion services
+const (
+	defaultRESTPort       = 1317
+	defaultGRPCPort       = 9090
+	defaultGRPCWebPort    = 9091
+	defaultPrometheusPort = 26660
+)
+
+// grpcNodeInfoMethod defines the gRPC method called to verify a gRPC or gRPC-web
+// endpoint. It is a query without side effects served by Cosmos SDK nodes.
+const grpcNodeInfoMethod = "/cosmos.base.tendermint.v1beta1.Service/GetNodeInfo"
+
+// emptyGRPCMessage defines a length-prefixed empty gRPC message.
+var emptyGRPCMessage = []byte{0, 0, 0, 0, 0}
+
+// Services defines the endpoints of the companion services a Tendermint node
+// exposes publicly as found during its last crawl. An endpoint is empty if the
+// service could not be verified.
+type Services struct {
+	REST       string `json:"rest" yaml:"rest"`
+	GRPC       string `json:"grpc" yaml:"grpc"`
+	GRPCWeb    string `json:"grpc_web" yaml:"grpc_web"`
+	Prometheus string `json:"prometheus" yaml:"prometheus"`
+}
+
+// ServicesConfig defines the ports probed for companion services. A service is
+// not probed if its port is zero.
+type ServicesConfig struct {
+	RESTPort       uint `toml:"rest_port"`
+	GRPCPort       uint `toml:"grpc_port"`
+	GRPCWebPort    uint `toml:"grpc_web_port"`
+	PrometheusPort uint `toml:"prometheus_port"`
+}
+
+func init() {
+	RegisterProberFactory(ServicesProberName, func(pcfg config.ProberConfig) (Prober, error) {
+		cfg := ServicesConfig{
+			RESTPort:       defaultRESTPort,
+			GRPCPort:       defaultGRPCPort,
+			GRPCWebPort:    defaultGRPCWebPort,
+			PrometheusPort: defaultPrometheusPort,
+		}
+
+		if err := pcfg.Decode(&cfg); err != nil {
+			return nil, err
+		}
+
+		return servicesProber{cfg: cfg}, nil
+	})
+}
+
+// servicesProber implements a Prober which discovers the companion services
+// running on a node's host.
+type servicesProber struct {
+	cfg ServicesConfig
+}
+
+func (servicesProber) Name() string {
+	return ServicesProberName
+}
+
+func (p servicesProber) Probe(ctx context.Context, target ProbeTarget, node *Node) error {
+	node.Services = DiscoverServices(ctx, target.dialer(), target.Host, p.cfg)
+	return nil
+}
+
+// Endpoint returns the endpoint of a service by its name.
+func (s Services) Endpoint(service string) string {
+	switch service {
+	case ServiceREST:
+		return s.REST
+
+	case ServiceGRPC:
+		return s.GRPC
+
+	case ServiceGRPCWeb:
+		return s.GRPCWeb
+
+	case ServicePrometheus:
+		return s.Prometheus
+
+	default:
+		return ""
+	}
+}
+
+// DiscoverServices probes a node's host for the configured companion services.
+// Each service is verified with a request of its protocol rather than a bare
+// dial: the REST server must serve node info, the gRPC and gRPC-web servers
+// must answer a gRPC call and the Prometheus server must serve metrics. A
+// service is skipped if its port is zero. All requests are made via the dialer.
+func DiscoverServices(ctx context.Context, dialer Dialer, host string, cfg ServicesConfig) Services {
+	services := Services{}
+	client := newHTTPClient(ctx, dialer, clientTimeout)
+
+	if cfg.RESTPort != 0 {
+		endpoint := fmt.Sprintf("http://%s", net.JoinHostPort(host, fmt.Sprint(cfg.RESTPort)))
+		if probeREST(client, endpoint) {
+			services.REST = endpoint
+		}
+	}
+
+	if cfg.GRPCPort != 0 {
+		// the HTTP/2 transport keeps its connection open until closed
+		h2cTransport := newH2CTransport(ctx, dialer)
+		defer h2cTransport.CloseIdleConnections()
+
+		h2cClient := &http.Client{
+			Timeout:   clientTimeout,
+			Transport: contextTransport{ctx: ctx, base: h2cTransport},
+		}
+
+		endpoint := net.JoinHostPort(host, fmt.Sprint(cfg.GRPCPort))
+		if probeGRPC(h2cClient, endpoint) {
+			services.GRPC = endpoint
+		}
+	}
+
+	if cfg.GRPCWebPort != 0 {
+		endpoint := fmt.Sprintf("http://%s", net.JoinHostPort(host, fmt.Sprint(cfg.GRPCWebPort)))
+		if probeGRPCWeb(client, endpoint) {
+			services.GRPCWeb = endpoint
+		}
+	}
+
+	if cfg.PrometheusPort != 0 {
+		endpoint := fmt.Sprintf("http://%s", net.JoinHostPort(host, fmt.Sprint(cfg.PrometheusPort)))
+		if probePrometheus(client, endpoint) {
+			services.Prometheus = endpoint
+		}
+	}
+
+	return services
+}
+
+// newH2CTransport returns an HTTP/2 transport which connects via the dialer
+// and speaks HTTP/2 over cleartext TCP as required by gRPC servers without TLS.
+// The caller is responsible for closing its idle connections.
+func newH2CTransport(ctx context.Context, dialer Dialer) *http2.Transport {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			dialCtx, cancel := context.WithTimeout(ctx, clientTimeout)
+			defer cancel()
+
+			return dialer.DialContext(dialCtx, network, addr)
+		},
+	}
+}
+
+// probeREST returns true if a Cosmos SDK REST server serves node info at the
+// endpoint, either via the legacy LCD route or the gRPC gateway.
+func probeREST(client *http.Client, endpoint string) bool {
+	for _, path := range []string{"/node_info", "/cosmos/base/tendermint/v1beta1/node_info"} {
+		body, status, err := httpGet(client, endpoint+path)
+		if err == nil && status == http.StatusOK && strings.Contains(body, `"network"`) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// probeGRPC returns true if a gRPC server answers a call at the endpoint. Any
+// gRPC response, including an error status, verifies the server.
+func probeGRPC(client *http.Client, endpoint string) bool {
+	req, err := http.NewRequest(http.MethodPost, "http://"+endpoint+grpcNodeInfoMethod, bytes.NewReader(emptyGRPCMessage))
+	if err != nil {
+		return false
+	}
+
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	return isGRPCResponse(client, req, "application/grpc")
+}
+
+// probeGRPCWeb returns true if a gRPC-web server answers a call at the
+// endpoint.
+func probeGRPCWeb(client *http.Client, endpoint string) bool {
+	req, err := http.NewRequest(http.MethodPost, endpoint+grpcNodeInfoMethod, bytes.NewReader(emptyGRPCMessage))
+	if err != nil {
+		return false
+	}
+
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("X-Grpc-Web", "1")
+
+	return isGRPCResponse(client, req, "application/grpc-web")
+}
+
+func isGRPCResponse(client *http.Client, req *http.Request, contentType string) bool {
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), contentType)
+}
+
+// probePrometheus returns true if a Prometheus metrics server serves metrics
+// in the text exposition format at the endpoint.
+func probePrometheus(client *http.Client, endpoint string) bool {
+	body, status, err := httpGet(client, endpoint+"/metrics")
+	return err == nil && status == http.StatusOK && strings.Contains(body, "# TYPE ")
+}
@@ -0,0 +1,115 @@
+package crawl_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func serverPort(t *testing.T, srv *httptest.Server) uint {
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	x, err := strconv.ParseUint(port, 10, 32)
+	require.NoError(t, err)
+
+	return uint(x)
+}
+
+// countingListener implements a net.Listener which counts the connections it
+// accepted that are still open.
+type countingListener struct {
+	net.Listener
+	open int64
+}
+
+type countingConn struct {
+	net.Conn
+	l    *countingListener
+	once int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&l.open, 1)
+	return &countingConn{Conn: c, l: l}, nil
+}
+
+func (l *countingListener) Open() int64 {
+	return atomic.LoadInt64(&l.open)
+}
+
+func (c *countingConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.once, 0, 1) {
+		atomic.AddInt64(&c.l.open, -1)
+	}
+
+	return c.Conn.Close()
+}
+
+func TestDiscoverServices(t *testing.T) {
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/node_info" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, `{"node_info":{"network":"chain-0"}}`)
+	}))
+	defer rest.Close()
+
+	grpcHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("Grpc-Status", "12")
+	})
+
+	grpc := httptest.NewUnstartedServer(h2c.NewHandler(grpcHandler, &http2.Server{}))
+	grpcListener := &countingListener{Listener: grpc.Listener}
+	grpc.Listener = grpcListener
+	grpc.Start()
+	defer grpc.Close()
+
+	grpcWeb := httptest.NewServer(grpcHandler)
+	defer grpcWeb.Close()
+
+	// a server that is not a Prometheus metrics server must not be discovered
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer prometheus.Close()
+
+	services := crawl.DiscoverServices(context.Background(), &net.Dialer{}, "127.0.0.1", crawl.ServicesConfig{
+		RESTPort:       serverPort(t, rest),
+		GRPCPort:       serverPort(t, grpc),
+		GRPCWebPort:    serverPort(t, grpcWeb),
+		PrometheusPort: serverPort(t, prometheus),
+	})
+
+	require.Equal(t, rest.URL, services.REST)
+	require.Equal(t, grpc.Listener.Addr().String(), services.GRPC)
+	require.Equal(t, grpcWeb.URL, services.GRPCWeb)
+	require.Empty(t, services.Prometheus)
+	require.Equal(t, rest.URL, services.Endpoint(crawl.ServiceREST))
+
+	// the gRPC probe must not leave its HTTP/2 connection open
+	require.Eventually(t, func() bool { return grpcListener.Open() == 0 }, time.Second, 10*time.Millisecond)
+
+	// services are not probed if their port is not set
+	services = crawl.DiscoverServices(context.Background(), &net.Dialer{}, "127.0.0.1", crawl.ServicesConfig{})
+	require.Equal(t, crawl.Services{}, services)
+}
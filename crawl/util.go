@@ -1,21 +1,162 @@
 package crawl
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/harwoeck/ipstack"
+	"github.com/tendermint/tendermint/p2p"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
-	libclient "github.com/tendermint/tendermint/rpc/lib/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 )
 
 var clientTimeout = 2 * time.Second
 
-func newRPCClient(remote string) *rpcclient.HTTP {
-	httpClient := libclient.DefaultHTTPClient(remote)
-	httpClient.Timeout = clientTimeout
+// maxResponseSize defines the maximum number of bytes read from the body of an
+// HTTP response of a crawled node, which may be run by anyone.
+const maxResponseSize = 512 << 10
+
+// errResponseTooLarge defines an error for a response body which exceeds the
+// maximum size of its transport.
+var errResponseTooLarge = errors.New("response body too large")
+
+// connLimiter implements a counting semaphore that caps the number of
+// outbound connections the crawler has open at any given time.
+type connLimiter chan struct{}
+
+func newConnLimiter(maxConns uint) connLimiter {
+	return make(connLimiter, maxConns)
+}
+
+// acquire blocks until a connection slot is available.
+func (l connLimiter) acquire() {
+	l <- struct{}{}
+}
+
+// release frees a previously acquired connection slot.
+func (l connLimiter) release() {
+	<-l
+}
+
+// contextTransport implements an http.RoundTripper which attaches a context to
+// every request so that in-flight requests are canceled along with it.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// schemeTransport implements an http.RoundTripper which sends every request
+// with the given URL scheme. Tendermint's RPC client sends requests to https
+// remotes as plain HTTP, so their scheme is restored before sending.
+type schemeTransport struct {
+	scheme string
+	base   http.RoundTripper
+}
+
+func (t schemeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.scheme
+
+	return t.base.RoundTrip(req)
+}
+
+// limitTransport implements an http.RoundTripper which limits the size of
+// response bodies. Reading a body beyond the limit fails with
+// errResponseTooLarge, so oversized responses are never decoded.
+type limitTransport struct {
+	limit int64
+	base  http.RoundTripper
+}
+
+// limitedBody implements a response body which fails once more than its
+// remaining number of bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (t limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit}
+	return resp, nil
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	// read one byte more than remains to detect bodies exceeding the limit
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+
+		return n, errResponseTooLarge
+	}
+
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// newNodeTransport returns an http.RoundTripper for requests to crawled nodes
+// which connects via the dialer and limits response bodies to the given size.
+// Connections are closed after each request so they're accounted for by the
+// crawler's connection limiter and compression is disabled to prevent GZIP
+// bombs as does Tendermint's default client.
+func newNodeTransport(dialer Dialer, limit int64) http.RoundTripper {
+	transport := &http.Transport{
+		DialContext:        dialer.DialContext,
+		DisableKeepAlives:  true,
+		DisableCompression: true,
+	}
+
+	return limitTransport{limit: limit, base: transport}
+}
+
+// newHTTPClient returns an HTTP client which connects via the dialer, whose
+// requests are canceled along with the context and which does not keep
+// connections alive between requests.
+func newHTTPClient(ctx context.Context, dialer Dialer, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: contextTransport{ctx: ctx, base: newNodeTransport(dialer, maxResponseSize)},
+	}
+}
+
+func newRPCClient(ctx context.Context, dialer Dialer, remote string) *rpcclient.HTTP {
+	return newRPCClientWithLimits(ctx, dialer, remote, clientTimeout, maxResponseSize)
+}
+
+// newRPCClientWithLimits returns a Tendermint RPC client of the remote whose
+// requests time out after the given duration and whose responses are limited
+// to the given size.
+func newRPCClientWithLimits(ctx context.Context, dialer Dialer, remote string, timeout time.Duration, limit int64) *rpcclient.HTTP {
+	base := newNodeTransport(dialer, limit)
+	if u, err := url.Parse(remote); err == nil && strings.EqualFold(u.Scheme, "https") {
+		base = schemeTransport{scheme: "https", base: base}
+	}
+
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: contextTransport{ctx: ctx, base: statusTransport{base: base}},
+	}
+
 	return rpcclient.NewHTTPWithClient(remote, "/websocket", httpClient)
 }
 
@@ -28,13 +169,42 @@ func parsePort(nodeAddr string) string {
 	return u.Port()
 }
 
-func parseHostname(nodeAddr string) string {
+// parseNodeAddr parses a node address from the node pool, which is either an
+// RPC address (e.g. https://1.2.3.4:443) or a P2P address in the form of
+// id@host:port. It returns the expected node ID and host along with the RPC
+// scheme and port and the P2P port, where a value is empty if it cannot be
+// derived from the address.
+func parseNodeAddr(nodeAddr string) (id, host, rpcScheme, rpcPort, p2pPort string) {
+	if i := strings.Index(nodeAddr, "@"); i >= 0 && !strings.Contains(nodeAddr, "://") {
+		host, port, err := net.SplitHostPort(nodeAddr[i+1:])
+		if err != nil {
+			return "", "", "", "", ""
+		}
+
+		return strings.ToLower(nodeAddr[:i]), host, "", "", port
+	}
+
 	u, err := url.Parse(nodeAddr)
+	if err != nil {
+		return "", "", "", "", ""
+	}
+
+	return "", u.Hostname(), strings.ToLower(u.Scheme), u.Port(), ""
+}
+
+// parseListenPort returns the port of a NodeInfo listen address such as
+// tcp://0.0.0.0:26656. An empty string is returned if it cannot be parsed.
+func parseListenPort(listenAddr string) string {
+	if i := strings.Index(listenAddr, "://"); i >= 0 {
+		listenAddr = listenAddr[i+3:]
+	}
+
+	_, port, err := net.SplitHostPort(listenAddr)
 	if err != nil {
 		return ""
 	}
 
-	return u.Hostname()
+	return port
 }
 
 func locationFromIPResp(r *ipstack.Response) Location {
@@ -47,14 +217,53 @@ func locationFromIPResp(r *ipstack.Response) Location {
 	}
 }
 
-// PingAddress attempts to ping a P2P Tendermint address returning true if the
-// node is reachable and false otherwise.
-func PingAddress(address string, t int64) bool {
-	conn, err := net.DialTimeout("tcp", address, time.Duration(t)*time.Second)
-	if err != nil {
-		return false
+func syncInfoFromStatus(s *ctypes.ResultStatus) SyncInfo {
+	return SyncInfo{
+		LatestBlockHash:   s.SyncInfo.LatestBlockHash.String(),
+		LatestAppHash:     s.SyncInfo.LatestAppHash.String(),
+		LatestBlockHeight: s.SyncInfo.LatestBlockHeight,
+		LatestBlockTime:   s.SyncInfo.LatestBlockTime.UTC().Format(time.RFC3339),
+		CatchingUp:        s.SyncInfo.CatchingUp,
 	}
+}
+
+func netInfoFromResult(r *ctypes.ResultNetInfo) NetInfo {
+	info := NetInfo{
+		Listeners: r.Listeners,
+		NumPeers:  r.NPeers,
+	}
+
+	for _, p := range r.Peers {
+		if p.IsOutbound {
+			info.OutboundPeers++
+		} else {
+			info.InboundPeers++
+		}
+
+		info.SendRate += p.ConnectionStatus.SendMonitor.CurRate
+		info.RecvRate += p.ConnectionStatus.RecvMonitor.CurRate
 
-	defer conn.Close()
-	return true
+		for _, ch := range p.ConnectionStatus.Channels {
+			info.SendQueueSize += ch.SendQueueSize
+			info.SendQueueCapacity += ch.SendQueueCapacity
+		}
+	}
+
+	return info
+}
+
+func appInfoFromResult(r *ctypes.ResultABCIInfo) AppInfo {
+	return AppInfo{
+		Name:        r.Response.Data,
+		Version:     r.Response.Version,
+		BlockHeight: r.Response.LastBlockHeight,
+	}
+}
+
+func protocolVersionFromNodeInfo(ni p2p.DefaultNodeInfo) ProtocolVersion {
+	return ProtocolVersion{
+		P2P:   uint64(ni.ProtocolVersion.P2P),
+		Block: uint64(ni.ProtocolVersion.Block),
+		App:   uint64(ni.ProtocolVersion.App),
+	}
 }
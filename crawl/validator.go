@@ -0,0 +1,97 @@
+package crawl
+
+import (
+	"sync"
+
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// Node roles
+const (
+	NodeRoleValidator = "validator"
+	NodeRoleFullNode  = "full_node"
+)
+
+// ValidatorSets caches the validator set of every crawled network by
+// validator address. A network's set is refreshed at most once per crawl
+// round. Until it is refreshed, the set of the previous round is used.
+type ValidatorSets struct {
+	mtx     sync.Mutex
+	sets    map[string]map[string]int64
+	fetched map[string]bool
+}
+
+// NewValidatorSets returns an empty validator set cache.
+func NewValidatorSets() *ValidatorSets {
+	return &ValidatorSets{
+		sets:    make(map[string]map[string]int64),
+		fetched: make(map[string]bool),
+	}
+}
+
+// Reset marks the validator sets of all networks as stale so they are
+// refreshed during the next crawl round.
+func (vs *ValidatorSets) Reset() {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	vs.fetched = make(map[string]bool)
+}
+
+// Claim returns true if a network's validator set is stale and marks it as
+// being refreshed so that only a single caller refreshes it per round.
+func (vs *ValidatorSets) Claim(network string) bool {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	if vs.fetched[network] {
+		return false
+	}
+
+	vs.fetched[network] = true
+	return true
+}
+
+// Unclaim marks a network's validator set as stale again after it failed to
+// be refreshed.
+func (vs *ValidatorSets) Unclaim(network string) {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	delete(vs.fetched, network)
+}
+
+// Set replaces the validator set of a network.
+func (vs *ValidatorSets) Set(network string, res *ctypes.ResultValidators) {
+	set := make(map[string]int64, len(res.Validators))
+	for _, v := range res.Validators {
+		set[v.Address.String()] = v.VotingPower
+	}
+
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	vs.sets[network] = set
+}
+
+// Role returns the role and voting power of a node given its validator address
+// and the voting power it reports for itself. The voting power found in the
+// network's validator set takes precedence over the reported one. The reported
+// power is kept for addresses missing from the set, as nodes may only return
+// the first page of a large validator set. A node is a validator if it has
+// non-zero voting power.
+func (vs *ValidatorSets) Role(network, address string, reportedPower int64) (string, int64) {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	power := reportedPower
+	if p, ok := vs.sets[network][address]; ok {
+		power = p
+	}
+
+	if power > 0 {
+		return NodeRoleValidator, power
+	}
+
+	return NodeRoleFullNode, 0
+}
@@ -0,0 +1,59 @@
+package crawl_test
+
+import (
+	"testing"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestValidatorSets_Role(t *testing.T) {
+	vs := crawl.NewValidatorSets()
+
+	val1 := types.NewValidator(ed25519.GenPrivKey().PubKey(), 10)
+	val2 := types.NewValidator(ed25519.GenPrivKey().PubKey(), 20)
+
+	// the reported voting power is used until the validator set is known
+	role, power := vs.Role("chain-0", val1.Address.String(), 5)
+	require.Equal(t, crawl.NodeRoleValidator, role)
+	require.Equal(t, int64(5), power)
+
+	role, power = vs.Role("chain-0", val1.Address.String(), 0)
+	require.Equal(t, crawl.NodeRoleFullNode, role)
+	require.Equal(t, int64(0), power)
+
+	require.True(t, vs.Claim("chain-0"))
+	require.False(t, vs.Claim("chain-0"))
+	vs.Set("chain-0", &ctypes.ResultValidators{Validators: []*types.Validator{val1}})
+
+	role, power = vs.Role("chain-0", val1.Address.String(), 0)
+	require.Equal(t, crawl.NodeRoleValidator, role)
+	require.Equal(t, int64(10), power)
+
+	// the set takes precedence over the reported voting power
+	role, power = vs.Role("chain-0", val1.Address.String(), 5)
+	require.Equal(t, crawl.NodeRoleValidator, role)
+	require.Equal(t, int64(10), power)
+
+	// validators missing from the set, e.g. beyond its first page, keep their
+	// reported voting power
+	role, power = vs.Role("chain-0", val2.Address.String(), 20)
+	require.Equal(t, crawl.NodeRoleValidator, role)
+	require.Equal(t, int64(20), power)
+
+	role, power = vs.Role("chain-0", val2.Address.String(), 0)
+	require.Equal(t, crawl.NodeRoleFullNode, role)
+	require.Equal(t, int64(0), power)
+
+	// validator sets are scoped by network
+	role, _ = vs.Role("chain-1", val1.Address.String(), 0)
+	require.Equal(t, crawl.NodeRoleFullNode, role)
+
+	vs.Reset()
+	require.True(t, vs.Claim("chain-0"))
+	vs.Unclaim("chain-0")
+	require.True(t, vs.Claim("chain-0"))
+}
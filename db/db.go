@@ -1,6 +1,7 @@
 package db
 
 import (
+	"bytes"
 	"path/filepath"
 
 	badger "github.com/dgraph-io/badger/v2"
@@ -14,6 +15,7 @@ type (
 		Set(key, value []byte) error
 		Delete(key []byte) error
 		IteratePrefix(prefix []byte, cb func(k, v []byte) bool)
+		ReverseIteratePrefix(prefix, end []byte, cb func(k, v []byte) bool)
 		Close() error
 	}
 
@@ -109,6 +111,60 @@ func (bdb *BadgerDB) IteratePrefix(prefix []byte, cb func(k, v []byte) bool) {
 	})
 }
 
+// ReverseIteratePrefix iterates over a series of key/value pairs where each key
+// contains the provided prefix in descending key order. Iteration starts at the
+// greatest key below end or, if end is nil, at the greatest key. For each
+// key/value pair, a cb function is invoked. If cb returns true, iteration is
+// halted.
+func (bdb *BadgerDB) ReverseIteratePrefix(prefix, end []byte, cb func(k, v []byte) bool) {
+	if end == nil {
+		end = prefixEnd(prefix)
+	}
+
+	_ = bdb.db.View(func(tx *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// seeking in reverse positions at the greatest key less than or equal to
+		// end, which itself is excluded
+		it.Seek(end)
+		if it.Valid() && bytes.Equal(it.Item().Key(), end) {
+			it.Next()
+		}
+
+		for ; it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			k := item.Key()
+			v, _ := item.ValueCopy(nil)
+
+			if cb(k, v) {
+				return nil
+			}
+		}
+
+		return nil
+	})
+}
+
+// prefixEnd returns the smallest key greater than all keys containing the
+// provided prefix. Nil is returned if there is no such key, in which case a
+// reverse seek starts at the greatest key.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
 // Close closes the Badger DB instance and returns an error upon failure.
 func (bdb *BadgerDB) Close() error {
 	return bdb.db.Close()
@@ -91,6 +91,76 @@ func TestIteratePrefix(t *testing.T) {
 	require.Len(t, values, half)
 }
 
+func TestReverseIteratePrefix(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	for _, key := range []string{"a/0", "a/1", "a/2", "a/3", "b/0", "a\xff"} {
+		require.NoError(t, bdb.Set([]byte(key), []byte(key)))
+	}
+
+	keys := []string{}
+	bdb.ReverseIteratePrefix([]byte("a/"), nil, func(k, _ []byte) bool {
+		keys = append(keys, string(k))
+		return false
+	})
+
+	require.Equal(t, []string{"a/3", "a/2", "a/1", "a/0"}, keys)
+
+	// iteration starts below end and halts once cb returns true
+	keys = []string{}
+	bdb.ReverseIteratePrefix([]byte("a/"), []byte("a/2"), func(k, _ []byte) bool {
+		keys = append(keys, string(k))
+		return len(keys) == 1
+	})
+
+	require.Equal(t, []string{"a/1"}, keys)
+
+	pdb := db.NewPrefixDB(bdb, []byte("a/"))
+
+	keys = []string{}
+	pdb.ReverseIteratePrefix(nil, []byte("3"), func(k, _ []byte) bool {
+		keys = append(keys, string(k))
+		return false
+	})
+
+	require.Equal(t, []string{"2", "1", "0"}, keys)
+}
+
+func TestPrefixDB(t *testing.T) {
+	bdb, err := db.NewBadgerMemDB()
+	require.NoError(t, err)
+
+	pdb1 := db.NewPrefixDB(bdb, []byte("network/chain-0/"))
+	pdb2 := db.NewPrefixDB(bdb, []byte("network/chain-1/"))
+
+	key, value := []byte("node/0"), []byte("value")
+	require.NoError(t, pdb1.Set(key, value))
+	require.True(t, pdb1.Has(key))
+	require.False(t, pdb2.Has(key))
+	require.False(t, bdb.Has(key))
+	require.True(t, bdb.Has([]byte("network/chain-0/node/0")))
+
+	bz, err := pdb1.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, value, bz)
+
+	keys := []string{}
+	pdb1.IteratePrefix([]byte("node/"), func(k, _ []byte) bool {
+		keys = append(keys, string(k))
+		return false
+	})
+
+	require.Equal(t, []string{"node/0"}, keys)
+
+	require.NoError(t, pdb1.Delete(key))
+	require.False(t, pdb1.Has(key))
+
+	// closing a prefix DB leaves the underlying DB open
+	require.NoError(t, pdb1.Close())
+	require.NoError(t, bdb.Set(key, value))
+}
+
 func TestClose(t *testing.T) {
 	bdb, err := db.NewBadgerMemDB()
 	require.NoError(t, err)
@@ -0,0 +1,67 @@
+package db
+
+// PrefixDB defines a wrapper type around a DB that implements the DB interface
+// by prepending a prefix to every key. It allows for independent keyspaces to
+// share a single underlying DB.
+type PrefixDB struct {
+	db     DB
+	prefix []byte
+}
+
+// NewPrefixDB returns a wrapper around a DB that scopes all keys by the given
+// prefix.
+func NewPrefixDB(db DB, prefix []byte) DB {
+	return &PrefixDB{db: db, prefix: prefix}
+}
+
+func (pdb *PrefixDB) key(key []byte) []byte {
+	return append(append([]byte{}, pdb.prefix...), key...)
+}
+
+// Get returns a value for a given key within the prefix.
+func (pdb *PrefixDB) Get(key []byte) ([]byte, error) {
+	return pdb.db.Get(pdb.key(key))
+}
+
+// Has returns a boolean determining if the prefix contains a given key or not.
+func (pdb *PrefixDB) Has(key []byte) bool {
+	return pdb.db.Has(pdb.key(key))
+}
+
+// Set sets a key/value pair within the prefix.
+func (pdb *PrefixDB) Set(key, value []byte) error {
+	return pdb.db.Set(pdb.key(key), value)
+}
+
+// Delete removes a value by key within the prefix.
+func (pdb *PrefixDB) Delete(key []byte) error {
+	return pdb.db.Delete(pdb.key(key))
+}
+
+// IteratePrefix iterates over a series of key/value pairs within the prefix
+// where each key contains the provided prefix. Keys are passed to cb without
+// the scoping prefix. If cb returns true, iteration is halted.
+func (pdb *PrefixDB) IteratePrefix(prefix []byte, cb func(k, v []byte) bool) {
+	pdb.db.IteratePrefix(pdb.key(prefix), func(k, v []byte) bool {
+		return cb(k[len(pdb.prefix):], v)
+	})
+}
+
+// ReverseIteratePrefix iterates over a series of key/value pairs within the
+// prefix where each key contains the provided prefix in descending key order,
+// starting below end if it is not nil. Keys are passed to cb without the
+// scoping prefix. If cb returns true, iteration is halted.
+func (pdb *PrefixDB) ReverseIteratePrefix(prefix, end []byte, cb func(k, v []byte) bool) {
+	if end != nil {
+		end = pdb.key(end)
+	}
+
+	pdb.db.ReverseIteratePrefix(pdb.key(prefix), end, func(k, v []byte) bool {
+		return cb(k[len(pdb.prefix):], v)
+	})
+}
+
+// Close is a no-op as the underlying DB is shared and closed by its owner.
+func (pdb *PrefixDB) Close() error {
+	return nil
+}
@@ -17,8 +17,10 @@ require (
 	github.com/stretchr/testify v1.4.0
 	github.com/swaggo/http-swagger v0.0.0-20200103000832-0e9263c4b516
 	github.com/swaggo/swag v1.6.4
+	github.com/tendermint/go-amino v0.14.1
 	github.com/tendermint/tendermint v0.32.8
 	github.com/vmihailenco/msgpack/v4 v4.3.1
 	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550 // indirect
+	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
 	gopkg.in/yaml.v2 v2.2.7
 )
@@ -31,17 +31,202 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/nodes": {
+        "/crawls": {
+            "get": {
+                "description": "Get the crawl runs of the last 30 days, ordered from newest to\noldest, with cursor-based pagination. A page holds 100 crawl runs\nunless a limit of at most 1000 is given. Each run contains the\nstatistics of a crawl round, i.e. the crawl of the node pool\nfrom a reseed until the pool is exhausted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "crawls"
+                ],
+                "summary": "Get crawl runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only return crawl runs started before the crawl run with this ID, i.e. the next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "The number of crawl runs per page, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter crawl runs by chain ID",
+                        "name": "network",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedCrawlRunsResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters, a limit above 1000 or failure to parse a crawl run",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/crawls/{id}": {
+            "get": {
+                "description": "Get the statistics of a crawl run by its ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "crawls"
+                ],
+                "summary": "Get a crawl run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The crawl run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crawl.CrawlRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Failure to parse the crawl run",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the crawl run",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/genesis": {
             "get": {
-                "description": "Get all nodes with optional pagination query parameters.",
+                "description": "Get all groups of nodes that share a chain ID and genesis hash,\nordered by number of nodes. Nodes of forks or unrelated networks\nthat reuse a chain ID are in separate groups.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "nodes"
                 ],
-                "summary": "Get all nodes",
+                "summary": "Get genesis groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter groups by chain ID",
+                        "name": "network",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.GenesisGroup"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failure to parse a node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/networks/{chain_id}/addrbook": {
+            "get": {
+                "description": "Export the online nodes of a network as a Tendermint addrbook.json\nfile. Nodes are placed in the buckets of vetted addresses. Nodes\nwithout a routable IP address are omitted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Export a network's address book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The chain ID of the network",
+                        "name": "chain_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Filter nodes by a minimum 7d uptime percentage",
+                        "name": "min_uptime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter nodes by whether they are synced, i.e. not catching up",
+                        "name": "synced",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crawl.AddrBook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter parameters or failure to parse a node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the network",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/networks/{chain_id}/nodes": {
+            "get": {
+                "description": "Get all nodes of a network, including offline nodes, with optional\npagination and filter query parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get all nodes of a network",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The chain ID of the network",
+                        "name": "chain_id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "The page number to query",
@@ -53,6 +238,96 @@ var doc = `{
                         "description": "The number of nodes per page",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by status (online | offline)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by role (validator | full_node)",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by ABCI application name",
+                        "name": "app_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by ABCI application version",
+                        "name": "app_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by genesis hash",
+                        "name": "genesis_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter audited nodes by whether they expose unsafe RPC routes",
+                        "name": "unsafe_rpc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by an exposed service (rest | grpc | grpc_web | prometheus)",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter nodes by whether they are catching up",
+                        "name": "catching_up",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a minimum latest block height",
+                        "name": "min_height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a maximum latest block height",
+                        "name": "max_height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes at least this many blocks behind the highest node of their network",
+                        "name": "lag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes whose latest block is at least this many seconds old",
+                        "name": "stale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a minimum number of connected peers",
+                        "name": "min_peers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a maximum number of connected peers",
+                        "name": "max_peers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Filter nodes by a maximum 90th percentile /status round-trip time in milliseconds over the last 24h",
+                        "name": "max_rpc_latency",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -63,7 +338,66 @@ var doc = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid pagination parameters or failure to parse a node",
+                        "description": "Invalid pagination or filter parameters or failure to parse a node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the network",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/networks/{chain_id}/persistent_peers": {
+            "get": {
+                "description": "Get the online nodes of a network as a comma-separated list of\npeers in the form of id@ip:port, ready to be used as Tendermint's\npersistent_peers option. Nodes without a routable IP address are\nomitted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get a network's persistent peers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The chain ID of the network",
+                        "name": "chain_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Filter nodes by a minimum 7d uptime percentage",
+                        "name": "min_uptime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter nodes by whether they are synced, i.e. not catching up",
+                        "name": "synced",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PersistentPeersResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter parameters or failure to parse a node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the network",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
@@ -71,49 +405,482 @@ var doc = `{
                 }
             }
         },
-        "/nodes/{address}": {
-            "get": {
-                "description": "Get node by address.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "nodes"
-                ],
-                "summary": "Get node",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "The node address (IP or resolvable to IP)",
-                        "name": "address",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/crawl.Node"
-                        }
-                    },
-                    "400": {
-                        "description": "Failure to parse the node",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Failure to find the node",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
-                        }
+        "/nodes": {
+            "get": {
+                "description": "Get all nodes, including offline nodes, with optional pagination\nand filter query parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get all nodes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The page number to query",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The number of nodes per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by status (online | offline)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by role (validator | full_node)",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by ABCI application name",
+                        "name": "app_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by ABCI application version",
+                        "name": "app_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by genesis hash",
+                        "name": "genesis_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter audited nodes by whether they expose unsafe RPC routes",
+                        "name": "unsafe_rpc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter nodes by an exposed service (rest | grpc | grpc_web | prometheus)",
+                        "name": "service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter nodes by whether they are catching up",
+                        "name": "catching_up",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a minimum latest block height",
+                        "name": "min_height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a maximum latest block height",
+                        "name": "max_height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes at least this many blocks behind the highest node of their network",
+                        "name": "lag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes whose latest block is at least this many seconds old",
+                        "name": "stale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a minimum number of connected peers",
+                        "name": "min_peers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter nodes by a maximum number of connected peers",
+                        "name": "max_peers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Filter nodes by a maximum 90th percentile /status round-trip time in milliseconds over the last 24h",
+                        "name": "max_rpc_latency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedNodesResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination or filter parameters or failure to parse a node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/nodes/{address}": {
+            "get": {
+                "description": "Get node by node ID or address. If the address does not contain a\nP2P port, the first node found on that address is returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get node",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The node ID or address (IP or resolvable to IP) with an optional P2P port",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crawl.Node"
+                        }
+                    },
+                    "400": {
+                        "description": "Failure to parse the node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/nodes/{address}/latency": {
+            "get": {
+                "description": "Get the latency of a node by node ID or address computed from its\nprobes within a window: the 50th, 90th and 99th percentile of the\nTCP connect round-trip time to its P2P port and of the round-trip\ntimes of its /status and /net_info RPC calls in milliseconds, along\nwith the number of failed probe stages by error class.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get node latency",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The node ID or address (IP or resolvable to IP) with an optional P2P port",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The window in seconds, at most 30 days (default 86400)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crawl.LatencyStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid window or failure to parse the node or its probe history",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/nodes/{address}/peers": {
+            "get": {
+                "description": "Get the peers a node by node ID or address was connected to\nduring its last crawl, i.e. the outgoing edges of the node in the\npeer graph.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get node peers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The node ID or address (IP or resolvable to IP) with an optional P2P port",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/crawl.Peer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failure to parse the node or its peers",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/nodes/{address}/probes": {
+            "get": {
+                "description": "Get the probe history of a node by node ID or address over the\nlast 30 days, ordered from oldest to newest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get node probe history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The node ID or address (IP or resolvable to IP) with an optional P2P port",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/crawl.ProbeResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failure to parse the node or its probe history",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Failure to find the node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{service}": {
+            "get": {
+                "description": "Get the endpoints of a companion service exposed by online nodes,\ne.g. public REST or gRPC endpoints.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nodes"
+                ],
+                "summary": "Get service endpoints",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The service (rest | grpc | grpc_web | prometheus)",
+                        "name": "service",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter endpoints by chain ID",
+                        "name": "network",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid service or failure to parse a node",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "crawl.AddrBook": {
+            "type": "object",
+            "properties": {
+                "addrs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crawl.KnownAddress"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "crawl.AppInfo": {
+            "type": "object",
+            "properties": {
+                "block_height": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "crawl.CrawlRun": {
+            "type": "object",
+            "properties": {
+                "attempted": {
+                    "type": "integer"
+                },
+                "discovered": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "network": {
+                    "type": "string"
+                },
+                "purged": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crawl.KnownAddress": {
+            "type": "object",
+            "properties": {
+                "addr": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "bucket_type": {
+                    "type": "integer"
+                },
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "last_attempt": {
+                    "type": "string"
+                },
+                "last_success": {
+                    "type": "string"
+                },
+                "src": {
+                    "type": "string"
+                }
+            }
+        },
+        "crawl.LatencyStats": {
+            "type": "object",
+            "properties": {
+                "connect_rtt_ms": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.Percentiles"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
                     }
+                },
+                "net_info_rtt_ms": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.Percentiles"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "status_rtt_ms": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.Percentiles"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "crawl.Location": {
             "type": "object",
             "properties": {
@@ -134,39 +901,304 @@ var doc = `{
                 }
             }
         },
+        "crawl.Metadata": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "crawl.NetInfo": {
+            "type": "object",
+            "properties": {
+                "listeners": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "n_inbound_peers": {
+                    "type": "integer"
+                },
+                "n_outbound_peers": {
+                    "type": "integer"
+                },
+                "n_peers": {
+                    "type": "integer"
+                },
+                "recv_rate": {
+                    "type": "integer"
+                },
+                "send_queue_capacity": {
+                    "type": "integer"
+                },
+                "send_queue_size": {
+                    "type": "integer"
+                },
+                "send_rate": {
+                    "type": "integer"
+                }
+            }
+        },
         "crawl.Node": {
             "type": "object",
             "properties": {
                 "address": {
                     "type": "string"
                 },
+                "app_info": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.AppInfo"
+                },
+                "channels": {
+                    "type": "string"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "first_seen": {
+                    "type": "string"
+                },
+                "genesis_hash": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "last_seen": {
+                    "type": "string"
+                },
                 "last_sync": {
                     "type": "string"
                 },
+                "latency": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.LatencyStats"
+                },
+                "listen_addr": {
+                    "type": "string"
+                },
                 "location": {
                     "type": "object",
                     "$ref": "#/definitions/crawl.Location"
                 },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.Metadata"
+                },
                 "moniker": {
                     "type": "string"
                 },
+                "net_info": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.NetInfo"
+                },
                 "network": {
                     "type": "string"
                 },
                 "p2p_port": {
                     "type": "string"
                 },
+                "protocol_version": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.ProtocolVersion"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "rpc_exposure": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.RPCExposure"
+                },
                 "rpc_port": {
                     "type": "string"
                 },
+                "rpc_scheme": {
+                    "type": "string"
+                },
+                "services": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.Services"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "sync_info": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.SyncInfo"
+                },
                 "tx_index": {
                     "type": "string"
                 },
+                "uptime": {
+                    "type": "object",
+                    "$ref": "#/definitions/crawl.Uptime"
+                },
+                "validator_address": {
+                    "type": "string"
+                },
                 "version": {
                     "type": "string"
+                },
+                "voting_power": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crawl.Peer": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "connection_duration_ms": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_outbound": {
+                    "type": "boolean"
+                },
+                "last_sync": {
+                    "type": "string"
+                },
+                "moniker": {
+                    "type": "string"
+                },
+                "p2p_port": {
+                    "type": "string"
+                },
+                "recv_rate": {
+                    "type": "integer"
+                },
+                "send_rate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crawl.Percentiles": {
+            "type": "object",
+            "properties": {
+                "p50": {
+                    "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                },
+                "p99": {
+                    "type": "number"
+                }
+            }
+        },
+        "crawl.ProbeResult": {
+            "type": "object",
+            "properties": {
+                "connect_rtt_ms": {
+                    "type": "number"
+                },
+                "error_class": {
+                    "type": "string"
+                },
+                "error_stage": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "net_info_rtt_ms": {
+                    "type": "number"
+                },
+                "status_rtt_ms": {
+                    "type": "number"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "crawl.ProtocolVersion": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "type": "integer"
+                },
+                "block": {
+                    "type": "integer"
+                },
+                "p2p": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crawl.RPCExposure": {
+            "type": "object",
+            "properties": {
+                "last_audit": {
+                    "type": "string"
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unsafe_routes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "crawl.Services": {
+            "type": "object",
+            "properties": {
+                "grpc": {
+                    "type": "string"
+                },
+                "grpc_web": {
+                    "type": "string"
+                },
+                "prometheus": {
+                    "type": "string"
+                },
+                "rest": {
+                    "type": "string"
+                }
+            }
+        },
+        "crawl.SyncInfo": {
+            "type": "object",
+            "properties": {
+                "catching_up": {
+                    "type": "boolean"
+                },
+                "latest_app_hash": {
+                    "type": "string"
+                },
+                "latest_block_hash": {
+                    "type": "string"
+                },
+                "latest_block_height": {
+                    "type": "integer"
+                },
+                "latest_block_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "crawl.Uptime": {
+            "type": "object",
+            "properties": {
+                "24h": {
+                    "type": "number"
+                },
+                "30d": {
+                    "type": "number"
+                },
+                "7d": {
+                    "type": "number"
                 }
             }
         },
@@ -178,6 +1210,37 @@ var doc = `{
                 }
             }
         },
+        "server.GenesisGroup": {
+            "type": "object",
+            "properties": {
+                "genesis_hash": {
+                    "type": "string"
+                },
+                "network": {
+                    "type": "string"
+                },
+                "nodes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.PaginatedCrawlRunsResp": {
+            "type": "object",
+            "properties": {
+                "crawls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crawl.CrawlRun"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
         "server.PaginatedNodesResp": {
             "type": "object",
             "properties": {
@@ -197,6 +1260,17 @@ var doc = `{
                     "type": "integer"
                 }
             }
+        },
+        "server.PersistentPeersResp": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "persistent_peers": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
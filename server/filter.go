@@ -0,0 +1,261 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fissionlabsio/tmcrawl/crawl"
+)
+
+// nodeFilter defines the set of query parameters nodes can be filtered by. A
+// zero value field does not filter nodes.
+type nodeFilter struct {
+	status     string
+	role       string
+	appName    string
+	appVersion string
+	genesis    string
+	unsafeRPC  *bool
+	service    string
+	catchingUp *bool
+	minHeight  int64
+	maxHeight  int64
+	minLag     int64
+	staleAfter time.Duration
+	minPeers   int64
+	maxPeers   int64
+	maxLatency float64
+}
+
+// parseNodeFilter parses a nodeFilter from a request's query parameters. An
+// error is returned if any of the parameters is invalid.
+func parseNodeFilter(r *http.Request) (nodeFilter, error) {
+	f := nodeFilter{}
+
+	f.status = r.FormValue("status")
+	if f.status != "" && f.status != crawl.NodeStatusOnline && f.status != crawl.NodeStatusOffline {
+		return f, fmt.Errorf("invalid status query: %s", f.status)
+	}
+
+	f.role = r.FormValue("role")
+	if f.role != "" && f.role != crawl.NodeRoleValidator && f.role != crawl.NodeRoleFullNode {
+		return f, fmt.Errorf("invalid role query: %s", f.role)
+	}
+
+	f.appName = r.FormValue("app_name")
+	f.appVersion = r.FormValue("app_version")
+	f.genesis = r.FormValue("genesis_hash")
+
+	f.service = r.FormValue("service")
+	if f.service != "" && !isService(f.service) {
+		return f, fmt.Errorf("invalid service query: %s", f.service)
+	}
+
+	if s := r.FormValue("unsafe_rpc"); s != "" {
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return f, fmt.Errorf("invalid unsafe_rpc query: %s", s)
+		}
+
+		f.unsafeRPC = &x
+	}
+
+	if s := r.FormValue("catching_up"); s != "" {
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return f, fmt.Errorf("invalid catching_up query: %s", s)
+		}
+
+		f.catchingUp = &x
+	}
+
+	for param, v := range map[string]*int64{
+		"min_height": &f.minHeight,
+		"max_height": &f.maxHeight,
+		"lag":        &f.minLag,
+		"min_peers":  &f.minPeers,
+		"max_peers":  &f.maxPeers,
+	} {
+		if s := r.FormValue(param); s != "" {
+			x, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || x <= 0 {
+				return f, fmt.Errorf("invalid %s query: %s", param, s)
+			}
+
+			*v = x
+		}
+	}
+
+	if s := r.FormValue("max_rpc_latency"); s != "" {
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil || x <= 0 {
+			return f, fmt.Errorf("invalid max_rpc_latency query: %s", s)
+		}
+
+		f.maxLatency = x
+	}
+
+	if s := r.FormValue("stale"); s != "" {
+		x, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || x == 0 {
+			return f, fmt.Errorf("invalid stale query: %s", s)
+		}
+
+		f.staleAfter = time.Duration(x) * time.Second
+	}
+
+	return f, nil
+}
+
+// filtersSync returns true if the filter queries the sync status of nodes.
+func (f nodeFilter) filtersSync() bool {
+	return f.catchingUp != nil || f.minHeight > 0 || f.maxHeight > 0 || f.minLag > 0 || f.staleAfter > 0
+}
+
+// filtersNetInfo returns true if the filter queries the connectivity of nodes.
+func (f nodeFilter) filtersNetInfo() bool {
+	return f.minPeers > 0 || f.maxPeers > 0
+}
+
+// match returns true if a node matches the filter. A node's lag is derived from
+// the highest block height known of its network, given by tips. Nodes with an
+// unknown sync status or connectivity do not match any of the respective
+// filters.
+func (f nodeFilter) match(n crawl.Node, tips map[string]int64, now time.Time) bool {
+	if f.status != "" && n.Status != f.status {
+		return false
+	}
+
+	if f.role != "" && n.Role != f.role {
+		return false
+	}
+
+	if f.appName != "" && n.AppInfo.Name != f.appName {
+		return false
+	}
+
+	if f.appVersion != "" && n.AppInfo.Version != f.appVersion {
+		return false
+	}
+
+	if f.genesis != "" && n.GenesisHash != f.genesis {
+		return false
+	}
+
+	if f.service != "" && n.Services.Endpoint(f.service) == "" {
+		return false
+	}
+
+	// nodes that have not been audited do not match
+	if f.unsafeRPC != nil && (n.RPCExposure.LastAudit == "" || n.RPCExposure.Unsafe() != *f.unsafeRPC) {
+		return false
+	}
+
+	// nodes without a measured RPC round-trip time do not match
+	if f.maxLatency > 0 && (n.Latency.Status.P90 == 0 || n.Latency.Status.P90 > f.maxLatency) {
+		return false
+	}
+
+	if f.filtersNetInfo() {
+		netInfo := n.NetInfo
+		if len(netInfo.Listeners) == 0 {
+			return false
+		}
+
+		if f.minPeers > 0 && int64(netInfo.NumPeers) < f.minPeers {
+			return false
+		}
+
+		if f.maxPeers > 0 && int64(netInfo.NumPeers) > f.maxPeers {
+			return false
+		}
+	}
+
+	if !f.filtersSync() {
+		return true
+	}
+
+	syncInfo := n.SyncInfo
+	if syncInfo.LatestBlockHeight == 0 {
+		return false
+	}
+
+	if f.catchingUp != nil && syncInfo.CatchingUp != *f.catchingUp {
+		return false
+	}
+
+	if f.minHeight > 0 && syncInfo.LatestBlockHeight < f.minHeight {
+		return false
+	}
+
+	if f.maxHeight > 0 && syncInfo.LatestBlockHeight > f.maxHeight {
+		return false
+	}
+
+	if f.minLag > 0 && tips[n.Network]-syncInfo.LatestBlockHeight < f.minLag {
+		return false
+	}
+
+	if f.staleAfter > 0 {
+		blockTime, err := time.Parse(time.RFC3339, syncInfo.LatestBlockTime)
+		if err != nil || now.Sub(blockTime) < f.staleAfter {
+			return false
+		}
+	}
+
+	return true
+}
+
+// networkTips returns the highest block height reported by any of the given
+// nodes per network.
+func networkTips(nodes []crawl.Node) map[string]int64 {
+	tips := make(map[string]int64)
+
+	for _, n := range nodes {
+		if n.SyncInfo.LatestBlockHeight > tips[n.Network] {
+			tips[n.Network] = n.SyncInfo.LatestBlockHeight
+		}
+	}
+
+	return tips
+}
+
+// isService returns true if the given name is a known companion service.
+func isService(service string) bool {
+	switch service {
+	case crawl.ServiceREST, crawl.ServiceGRPC, crawl.ServiceGRPCWeb, crawl.ServicePrometheus:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// parseAddrBookFilter parses an address book filter from a request's min_uptime
+// and synced query parameters. An error is returned if any of the parameters is
+// invalid.
+func parseAddrBookFilter(r *http.Request) (crawl.AddrBookFilter, error) {
+	f := crawl.AddrBookFilter{}
+
+	if s := r.FormValue("min_uptime"); s != "" {
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil || x < 0 || x > 100 {
+			return f, fmt.Errorf("invalid min_uptime query: %s", s)
+		}
+
+		f.MinUptime = x
+	}
+
+	if s := r.FormValue("synced"); s != "" {
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return f, fmt.Errorf("invalid synced query: %s", s)
+		}
+
+		f.Synced = x
+	}
+
+	return f, nil
+}
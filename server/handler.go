@@ -1,11 +1,16 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
+	"github.com/fissionlabsio/tmcrawl/config"
 	"github.com/fissionlabsio/tmcrawl/crawl"
 	"github.com/fissionlabsio/tmcrawl/db"
 	_ "github.com/fissionlabsio/tmcrawl/server/docs"
@@ -15,13 +20,41 @@ import (
 
 const (
 	methodGET = "GET"
+
+	defaultLatencyWindow = 24 * time.Hour
+	maxLatencyWindow     = 30 * 24 * time.Hour
+
+	defaultCrawlRunsLimit = 100
+	maxCrawlRunsLimit     = 1000
 )
 
-// RegisterRoutes registers all HTTP routes with the provided mux router.
-func RegisterRoutes(db db.DB, r *mux.Router) {
+// networkDB defines the keyspace of a crawled network profile.
+type networkDB struct {
+	chainID string
+	db      db.DB
+}
+
+// RegisterRoutes registers all HTTP routes with the provided mux router. The
+// global routes query the nodes of all provided network profiles.
+func RegisterRoutes(db db.DB, networks []config.NetworkConfig, r *mux.Router) {
+	ndbs := make([]networkDB, len(networks))
+	for i, n := range networks {
+		ndbs[i] = networkDB{chainID: n.ChainID, db: crawl.NetworkDB(db, n.ChainID)}
+	}
+
 	r.PathPrefix("/swagger/").Handler(httpswagger.WrapHandler)
-	r.HandleFunc("/api/v1/nodes", getNodesHandler(db)).Methods(methodGET)
-	r.HandleFunc("/api/v1/nodes/{address}", getNodeHandler(db)).Methods(methodGET)
+	r.HandleFunc("/api/v1/nodes", getNodesHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/nodes/{address}", getNodeHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/nodes/{address}/probes", getNodeProbesHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/nodes/{address}/latency", getNodeLatencyHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/nodes/{address}/peers", getNodePeersHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/networks/{chain_id}/nodes", getNetworkNodesHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/networks/{chain_id}/addrbook", getNetworkAddrBookHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/networks/{chain_id}/persistent_peers", getNetworkPersistentPeersHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/genesis", getGenesisGroupsHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/services/{service}", getServiceEndpointsHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/crawls", getCrawlRunsHandler(ndbs)).Methods(methodGET)
+	r.HandleFunc("/api/v1/crawls/{id}", getCrawlRunHandler(ndbs)).Methods(methodGET)
 }
 
 // PaginatedNodesResp defines a paginated search result of nodes.
@@ -32,80 +65,423 @@ type PaginatedNodesResp struct {
 	Nodes []crawl.Node `json:"nodes" yaml:"nodes"`
 }
 
+// PaginatedCrawlRunsResp defines a paginated search result of crawl runs. The
+// next cursor is the ID of the last crawl run returned and is empty if there
+// are no older crawl runs.
+type PaginatedCrawlRunsResp struct {
+	Limit      int              `json:"limit" yaml:"limit"`
+	NextCursor string           `json:"next_cursor" yaml:"next_cursor"`
+	Crawls     []crawl.CrawlRun `json:"crawls" yaml:"crawls"`
+}
+
+// PersistentPeersResp defines the persistent peers of a network along with
+// their number.
+type PersistentPeersResp struct {
+	Count           int    `json:"count" yaml:"count"`
+	PersistentPeers string `json:"persistent_peers" yaml:"persistent_peers"`
+}
+
+// GenesisGroup defines the set of nodes of a network that share a genesis.
+type GenesisGroup struct {
+	GenesisHash string `json:"genesis_hash" yaml:"genesis_hash"`
+	Network     string `json:"network" yaml:"network"`
+	Nodes       int    `json:"nodes" yaml:"nodes"`
+}
+
 // @Summary Get all nodes
-// @Description Get all nodes with optional pagination query parameters.
+// @Description Get all nodes, including offline nodes, with optional pagination
+// @Description and filter query parameters.
 // @Tags nodes
 // @Produce json
 // @Param page query int false "The page number to query"
 // @Param limit query int false "The number of nodes per page"
+// @Param status query string false "Filter nodes by status (online | offline)"
+// @Param role query string false "Filter nodes by role (validator | full_node)"
+// @Param app_name query string false "Filter nodes by ABCI application name"
+// @Param app_version query string false "Filter nodes by ABCI application version"
+// @Param genesis_hash query string false "Filter nodes by genesis hash"
+// @Param unsafe_rpc query bool false "Filter audited nodes by whether they expose unsafe RPC routes"
+// @Param service query string false "Filter nodes by an exposed service (rest | grpc | grpc_web | prometheus)"
+// @Param catching_up query bool false "Filter nodes by whether they are catching up"
+// @Param min_height query int false "Filter nodes by a minimum latest block height"
+// @Param max_height query int false "Filter nodes by a maximum latest block height"
+// @Param lag query int false "Filter nodes at least this many blocks behind the highest node of their network"
+// @Param stale query int false "Filter nodes whose latest block is at least this many seconds old"
+// @Param min_peers query int false "Filter nodes by a minimum number of connected peers"
+// @Param max_peers query int false "Filter nodes by a maximum number of connected peers"
+// @Param max_rpc_latency query number false "Filter nodes by a maximum 90th percentile /status round-trip time in milliseconds over the last 24h"
 // @Success 200 {object} server.PaginatedNodesResp
-// @Failure 400 {object} server.ErrorResponse "Invalid pagination parameters or failure to parse a node"
+// @Failure 400 {object} server.ErrorResponse "Invalid pagination or filter parameters or failure to parse a node"
 // @Router /nodes [get]
-func getNodesHandler(db db.DB) http.HandlerFunc {
+func getNodesHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeNodesResponse(w, r, ndbs, "")
+	}
+}
+
+// @Summary Get all nodes of a network
+// @Description Get all nodes of a network, including offline nodes, with optional
+// @Description pagination and filter query parameters.
+// @Tags nodes
+// @Produce json
+// @Param chain_id path string true "The chain ID of the network"
+// @Param page query int false "The page number to query"
+// @Param limit query int false "The number of nodes per page"
+// @Param status query string false "Filter nodes by status (online | offline)"
+// @Param role query string false "Filter nodes by role (validator | full_node)"
+// @Param app_name query string false "Filter nodes by ABCI application name"
+// @Param app_version query string false "Filter nodes by ABCI application version"
+// @Param genesis_hash query string false "Filter nodes by genesis hash"
+// @Param unsafe_rpc query bool false "Filter audited nodes by whether they expose unsafe RPC routes"
+// @Param service query string false "Filter nodes by an exposed service (rest | grpc | grpc_web | prometheus)"
+// @Param catching_up query bool false "Filter nodes by whether they are catching up"
+// @Param min_height query int false "Filter nodes by a minimum latest block height"
+// @Param max_height query int false "Filter nodes by a maximum latest block height"
+// @Param lag query int false "Filter nodes at least this many blocks behind the highest node of their network"
+// @Param stale query int false "Filter nodes whose latest block is at least this many seconds old"
+// @Param min_peers query int false "Filter nodes by a minimum number of connected peers"
+// @Param max_peers query int false "Filter nodes by a maximum number of connected peers"
+// @Param max_rpc_latency query number false "Filter nodes by a maximum 90th percentile /status round-trip time in milliseconds over the last 24h"
+// @Success 200 {object} server.PaginatedNodesResp
+// @Failure 400 {object} server.ErrorResponse "Invalid pagination or filter parameters or failure to parse a node"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the network"
+// @Router /networks/{chain_id}/nodes [get]
+func getNetworkNodesHandler(ndbs []networkDB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		pageStr := r.FormValue("page")
-		limitStr := r.FormValue("limit")
+		vars := mux.Vars(r)
+		chainID := vars["chain_id"]
 
-		page := 1
-		limit := 0
+		ndb, network, ok := findNetworkDB(ndbs, chainID)
+		if !ok {
+			writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find network: %s", chainID))
+			return
+		}
 
-		if pageStr != "" {
-			x, _ := strconv.Atoi(pageStr)
-			if x <= 0 {
-				writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("invalid page query: %s", pageStr))
-				return
-			}
+		writeNodesResponse(w, r, []networkDB{ndb}, network)
+	}
+}
 
-			page = x
+// @Summary Export a network's address book
+// @Description Export the online nodes of a network as a Tendermint addrbook.json
+// @Description file. Nodes are placed in the buckets of vetted addresses. Nodes
+// @Description without a routable IP address are omitted.
+// @Tags nodes
+// @Produce json
+// @Param chain_id path string true "The chain ID of the network"
+// @Param min_uptime query number false "Filter nodes by a minimum 7d uptime percentage"
+// @Param synced query bool false "Filter nodes by whether they are synced, i.e. not catching up"
+// @Success 200 {object} crawl.AddrBook
+// @Failure 400 {object} server.ErrorResponse "Invalid filter parameters or failure to parse a node"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the network"
+// @Router /networks/{chain_id}/addrbook [get]
+func getNetworkAddrBookHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		book, ok := exportAddrBook(w, r, ndbs)
+		if !ok {
+			return
 		}
 
-		if limitStr != "" {
-			x, _ := strconv.Atoi(limitStr)
-			if x <= 0 {
-				writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("invalid limit query: %s", limitStr))
-				return
-			}
+		bz, err := json.Marshal(book)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
 
-			limit = x
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// @Summary Get a network's persistent peers
+// @Description Get the online nodes of a network as a comma-separated list of
+// @Description peers in the form of id@ip:port, ready to be used as Tendermint's
+// @Description persistent_peers option. Nodes without a routable IP address are
+// @Description omitted.
+// @Tags nodes
+// @Produce json
+// @Param chain_id path string true "The chain ID of the network"
+// @Param min_uptime query number false "Filter nodes by a minimum 7d uptime percentage"
+// @Param synced query bool false "Filter nodes by whether they are synced, i.e. not catching up"
+// @Success 200 {object} server.PersistentPeersResp
+// @Failure 400 {object} server.ErrorResponse "Invalid filter parameters or failure to parse a node"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the network"
+// @Router /networks/{chain_id}/persistent_peers [get]
+func getNetworkPersistentPeersHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		book, ok := exportAddrBook(w, r, ndbs)
+		if !ok {
+			return
 		}
 
-		nodes := []crawl.Node{}
-		total := 0
+		resp := PersistentPeersResp{
+			Count:           len(book.Addrs),
+			PersistentPeers: book.PersistentPeers(),
+		}
 
-		var err error
-		db.IteratePrefix(crawl.NodeKeyPrefix, func(_, v []byte) bool {
+		bz, err := json.Marshal(resp)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// exportAddrBook returns the address book of the network of the request's
+// chain_id path parameter, filtered by the request's query parameters. An error
+// response is written and false returned if the address book cannot be
+// exported.
+func exportAddrBook(w http.ResponseWriter, r *http.Request, ndbs []networkDB) (crawl.AddrBook, bool) {
+	vars := mux.Vars(r)
+	chainID := vars["chain_id"]
+
+	ndb, network, ok := findNetworkDB(ndbs, chainID)
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find network: %s", chainID))
+		return crawl.AddrBook{}, false
+	}
+
+	filter, err := parseAddrBookFilter(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return crawl.AddrBook{}, false
+	}
+
+	book, err := crawl.ExportAddrBook(ndb.db, network, filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query nodes: %w", err))
+		return crawl.AddrBook{}, false
+	}
+
+	return book, true
+}
+
+// findNetworkDB returns the keyspace of a network along with the chain ID its
+// nodes must be filtered by, which is empty if the network has its own profile.
+// False is returned if no profile crawls the network.
+func findNetworkDB(ndbs []networkDB, chainID string) (networkDB, string, bool) {
+	for _, ndb := range ndbs {
+		if ndb.chainID == chainID {
+			return ndb, "", true
+		}
+	}
+
+	// the default profile crawls nodes of any network
+	for _, ndb := range ndbs {
+		if ndb.chainID == "" {
+			return ndb, chainID, true
+		}
+	}
+
+	return networkDB{}, "", false
+}
+
+// writeNodesResponse writes a paginated response of all nodes within the given
+// network keyspaces, filtered by the request's query parameters. If network is
+// non-empty, only nodes of that network are included.
+func writeNodesResponse(w http.ResponseWriter, r *http.Request, ndbs []networkDB, network string) {
+	page, limit, err := parsePagination(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := parseNodeFilter(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	nodes := []crawl.Node{}
+
+	for _, ndb := range ndbs {
+		ndb.db.IteratePrefix(crawl.NodeKeyPrefix, func(_, v []byte) bool {
 			node := new(crawl.Node)
 			err = node.Unmarshal(v)
 			if err != nil {
 				return true
 			}
 
-			total += 1
-			nodes = append(nodes, *node)
+			if network != "" && node.Network != network {
+				return false
+			}
 
+			nodes = append(nodes, *node)
 			return false
 		})
 
+		if err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query nodes: %w", err))
+		return
+	}
+
+	tips := networkTips(nodes)
+	now := time.Now().UTC()
+
+	filtered := []crawl.Node{}
+	for _, node := range nodes {
+		if filter.match(node, tips, now) {
+			filtered = append(filtered, node)
+		}
+	}
+
+	nodes = filtered
+	total := len(nodes)
+
+	start, end := paginate(len(nodes), page, limit, len(nodes))
+	if start < 0 || end < 0 {
+		nodes = []crawl.Node{}
+	} else {
+		nodes = nodes[start:end]
+	}
+
+	resp := PaginatedNodesResp{
+		Page:  page,
+		Limit: limit,
+		Total: total,
+		Nodes: nodes,
+	}
+
+	bz, err := json.Marshal(resp)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bz)
+}
+
+// @Summary Get genesis groups
+// @Description Get all groups of nodes that share a chain ID and genesis hash,
+// @Description ordered by number of nodes. Nodes of forks or unrelated networks
+// @Description that reuse a chain ID are in separate groups.
+// @Tags nodes
+// @Produce json
+// @Param network query string false "Filter groups by chain ID"
+// @Success 200 {array} server.GenesisGroup
+// @Failure 400 {object} server.ErrorResponse "Failure to parse a node"
+// @Router /genesis [get]
+func getGenesisGroupsHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		network := r.FormValue("network")
+		counts := make(map[GenesisGroup]int)
+
+		var err error
+		for _, ndb := range ndbs {
+			ndb.db.IteratePrefix(crawl.NodeKeyPrefix, func(_, v []byte) bool {
+				node := new(crawl.Node)
+				err = node.Unmarshal(v)
+				if err != nil {
+					return true
+				}
+
+				if node.GenesisHash == "" || (network != "" && node.Network != network) {
+					return false
+				}
+
+				counts[GenesisGroup{GenesisHash: node.GenesisHash, Network: node.Network}]++
+				return false
+			})
+
+			if err != nil {
+				break
+			}
+		}
+
 		if err != nil {
 			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query nodes: %w", err))
 			return
 		}
 
-		start, end := paginate(len(nodes), page, limit, len(nodes))
-		if start < 0 || end < 0 {
-			nodes = []crawl.Node{}
-		} else {
-			nodes = nodes[start:end]
+		groups := make([]GenesisGroup, 0, len(counts))
+		for g, n := range counts {
+			g.Nodes = n
+			groups = append(groups, g)
 		}
 
-		resp := PaginatedNodesResp{
-			Page:  page,
-			Limit: limit,
-			Total: total,
-			Nodes: nodes,
+		sort.Slice(groups, func(i, j int) bool {
+			if groups[i].Nodes != groups[j].Nodes {
+				return groups[i].Nodes > groups[j].Nodes
+			}
+
+			return groups[i].GenesisHash < groups[j].GenesisHash
+		})
+
+		bz, err := json.Marshal(groups)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// @Summary Get service endpoints
+// @Description Get the endpoints of a companion service exposed by online nodes,
+// @Description e.g. public REST or gRPC endpoints.
+// @Tags nodes
+// @Produce json
+// @Param service path string true "The service (rest | grpc | grpc_web | prometheus)"
+// @Param network query string false "Filter endpoints by chain ID"
+// @Success 200 {array} string
+// @Failure 400 {object} server.ErrorResponse "Invalid service or failure to parse a node"
+// @Router /services/{service} [get]
+func getServiceEndpointsHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		service := vars["service"]
+		network := r.FormValue("network")
+
+		if !isService(service) {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("invalid service: %s", service))
+			return
+		}
+
+		endpoints := []string{}
+
+		var err error
+		for _, ndb := range ndbs {
+			ndb.db.IteratePrefix(crawl.NodeKeyPrefix, func(_, v []byte) bool {
+				node := new(crawl.Node)
+				err = node.Unmarshal(v)
+				if err != nil {
+					return true
+				}
+
+				if node.Status != crawl.NodeStatusOnline || (network != "" && node.Network != network) {
+					return false
+				}
+
+				if endpoint := node.Services.Endpoint(service); endpoint != "" {
+					endpoints = append(endpoints, endpoint)
+				}
+
+				return false
+			})
+
+			if err != nil {
+				break
+			}
 		}
 
-		bz, err := json.Marshal(resp)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query nodes: %w", err))
+			return
+		}
+
+		sort.Strings(endpoints)
+
+		bz, err := json.Marshal(endpoints)
 		if err != nil {
 			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
 			return
@@ -117,33 +493,245 @@ func getNodesHandler(db db.DB) http.HandlerFunc {
 }
 
 // @Summary Get node
-// @Description Get node by address.
+// @Description Get node by node ID or address. If the address does not contain a
+// @Description P2P port, the first node found on that address is returned.
 // @Tags nodes
 // @Produce json
-// @Param address path string true "The node address (IP or resolvable to IP)"
+// @Param address path string true "The node ID or address (IP or resolvable to IP) with an optional P2P port"
 // @Success 200 {object} crawl.Node
 // @Failure 400 {object} server.ErrorResponse "Failure to parse the node"
 // @Failure 404 {object} server.ErrorResponse "Failure to find the node"
 // @Router /nodes/{address} [get]
-func getNodeHandler(db db.DB) http.HandlerFunc {
+func getNodeHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		address := vars["address"]
+
+		node, _, err := getNode(ndbs, address)
+		if errors.Is(err, crawl.ErrNodeNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find node: %s", address))
+			return
+		} else if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to decode node: %w", err))
+			return
+		}
+
+		bz, err := json.Marshal(node)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// @Summary Get crawl runs
+// @Description Get the crawl runs of the last 30 days, ordered from newest to
+// @Description oldest, with cursor-based pagination. A page holds 100 crawl runs
+// @Description unless a limit of at most 1000 is given. Each run contains the
+// @Description statistics of a crawl round, i.e. the crawl of the node pool
+// @Description from a reseed until the pool is exhausted.
+// @Tags crawls
+// @Produce json
+// @Param cursor query string false "Only return crawl runs started before the crawl run with this ID, i.e. the next_cursor of the previous page"
+// @Param limit query int false "The number of crawl runs per page, at most 1000" default(100)
+// @Param network query string false "Filter crawl runs by chain ID"
+// @Success 200 {object} server.PaginatedCrawlRunsResp
+// @Failure 400 {object} server.ErrorResponse "Invalid pagination parameters, a limit above 1000 or failure to parse a crawl run"
+// @Router /crawls [get]
+func getCrawlRunsHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, limit, err := parsePagination(r)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if limit == 0 {
+			limit = defaultCrawlRunsLimit
+		} else if limit > maxCrawlRunsLimit {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("invalid limit query: %d exceeds %d", limit, maxCrawlRunsLimit))
+			return
+		}
+
+		cursor := r.FormValue("cursor")
+		network := r.FormValue("network")
+		runs := []crawl.CrawlRun{}
+
+		for _, ndb := range ndbs {
+			if network != "" && ndb.chainID != network {
+				continue
+			}
+
+			// query one more run than the limit to know whether older runs remain
+			ndbRuns, err := crawl.GetCrawlRuns(ndb.db, cursor, limit+1)
+			if err != nil {
+				writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query crawl runs: %w", err))
+				return
+			}
+
+			runs = append(runs, ndbRuns...)
+		}
+
+		// run keys are ordered by start time across networks
+		sort.SliceStable(runs, func(i, j int) bool {
+			return bytes.Compare(crawl.CrawlRunKey(runs[i].ID), crawl.CrawlRunKey(runs[j].ID)) > 0
+		})
+
+		resp := PaginatedCrawlRunsResp{Limit: limit, Crawls: runs}
+
+		if len(runs) > limit {
+			resp.Crawls = runs[:limit]
+			resp.NextCursor = runs[limit-1].ID
+		}
+
+		bz, err := json.Marshal(resp)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// @Summary Get a crawl run
+// @Description Get the statistics of a crawl run by its ID.
+// @Tags crawls
+// @Produce json
+// @Param id path string true "The crawl run ID"
+// @Success 200 {object} crawl.CrawlRun
+// @Failure 400 {object} server.ErrorResponse "Failure to parse the crawl run"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the crawl run"
+// @Router /crawls/{id} [get]
+func getCrawlRunHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id := vars["id"]
+
+		var (
+			run crawl.CrawlRun
+			err = crawl.ErrCrawlRunNotFound
+		)
+
+		for _, ndb := range ndbs {
+			run, err = crawl.GetCrawlRun(ndb.db, id)
+			if !errors.Is(err, crawl.ErrCrawlRunNotFound) {
+				break
+			}
+		}
+
+		if errors.Is(err, crawl.ErrCrawlRunNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find crawl run: %s", id))
+			return
+		} else if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to decode crawl run: %w", err))
+			return
+		}
+
+		bz, err := json.Marshal(run)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// @Summary Get node probe history
+// @Description Get the probe history of a node by node ID or address over the
+// @Description last 30 days, ordered from oldest to newest.
+// @Tags nodes
+// @Produce json
+// @Param address path string true "The node ID or address (IP or resolvable to IP) with an optional P2P port"
+// @Success 200 {array} crawl.ProbeResult
+// @Failure 400 {object} server.ErrorResponse "Failure to parse the node or its probe history"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the node"
+// @Router /nodes/{address}/probes [get]
+func getNodeProbesHandler(ndbs []networkDB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		address := vars["address"]
 
-		if !db.Has(crawl.NodeKey(address)) {
+		node, db, err := getNode(ndbs, address)
+		if errors.Is(err, crawl.ErrNodeNotFound) {
 			writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find node: %s", address))
 			return
+		} else if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to decode node: %w", err))
+			return
 		}
 
-		bz, _ := db.Get(crawl.NodeKey(address))
+		history, err := crawl.GetProbeHistory(db, node.ID, time.Now().UTC().Add(-30*24*time.Hour))
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query probe history: %w", err))
+			return
+		}
+
+		bz, err := json.Marshal(history)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// @Summary Get node latency
+// @Description Get the latency of a node by node ID or address computed from its
+// @Description probes within a window: the 50th, 90th and 99th percentile of the
+// @Description TCP connect round-trip time to its P2P port and of the round-trip
+// @Description times of its /status and /net_info RPC calls in milliseconds, along
+// @Description with the number of failed probe stages by error class.
+// @Tags nodes
+// @Produce json
+// @Param address path string true "The node ID or address (IP or resolvable to IP) with an optional P2P port"
+// @Param window query int false "The window in seconds, at most 30 days (default 86400)"
+// @Success 200 {object} crawl.LatencyStats
+// @Failure 400 {object} server.ErrorResponse "Invalid window or failure to parse the node or its probe history"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the node"
+// @Router /nodes/{address}/latency [get]
+func getNodeLatencyHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		address := vars["address"]
 
-		node := new(crawl.Node)
-		if err := node.Unmarshal(bz); err != nil {
+		window := defaultLatencyWindow
+		if s := r.FormValue("window"); s != "" {
+			x, err := strconv.ParseUint(s, 10, 64)
+			if err != nil || x == 0 || time.Duration(x)*time.Second > maxLatencyWindow {
+				writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("invalid window query: %s", s))
+				return
+			}
+
+			window = time.Duration(x) * time.Second
+		}
+
+		node, db, err := getNode(ndbs, address)
+		if errors.Is(err, crawl.ErrNodeNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find node: %s", address))
+			return
+		} else if err != nil {
 			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to decode node: %w", err))
 			return
 		}
 
-		bz, err := json.Marshal(node)
+		since := time.Now().UTC().Add(-window)
+
+		history, err := crawl.GetProbeHistory(db, node.ID, since)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query probe history: %w", err))
+			return
+		}
+
+		bz, err := json.Marshal(crawl.ComputeLatencyStats(history, since))
 		if err != nil {
 			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
 			return
@@ -153,3 +741,60 @@ func getNodeHandler(db db.DB) http.HandlerFunc {
 		_, _ = w.Write(bz)
 	}
 }
+
+// @Summary Get node peers
+// @Description Get the peers a node by node ID or address was connected to
+// @Description during its last crawl, i.e. the outgoing edges of the node in the
+// @Description peer graph.
+// @Tags nodes
+// @Produce json
+// @Param address path string true "The node ID or address (IP or resolvable to IP) with an optional P2P port"
+// @Success 200 {array} crawl.Peer
+// @Failure 400 {object} server.ErrorResponse "Failure to parse the node or its peers"
+// @Failure 404 {object} server.ErrorResponse "Failure to find the node"
+// @Router /nodes/{address}/peers [get]
+func getNodePeersHandler(ndbs []networkDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		address := vars["address"]
+
+		node, db, err := getNode(ndbs, address)
+		if errors.Is(err, crawl.ErrNodeNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, fmt.Errorf("failed to find node: %s", address))
+			return
+		} else if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to decode node: %w", err))
+			return
+		}
+
+		peers, err := crawl.GetPeers(db, node.ID)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to query peers: %w", err))
+			return
+		}
+
+		bz, err := json.Marshal(peers)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Errorf("failed to encode response: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bz)
+	}
+}
+
+// getNode returns the first node found by node ID or address within the given
+// network keyspaces along with the keyspace it was found in.
+func getNode(ndbs []networkDB, idOrAddress string) (crawl.Node, db.DB, error) {
+	for _, ndb := range ndbs {
+		node, err := crawl.GetNode(ndb.db, idOrAddress)
+		if errors.Is(err, crawl.ErrNodeNotFound) {
+			continue
+		}
+
+		return node, ndb.db, err
+	}
+
+	return crawl.Node{}, nil, crawl.ErrNodeNotFound
+}
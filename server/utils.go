@@ -1,5 +1,37 @@
 package server
 
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// parsePagination parses the page and limit query parameters of a request. The
+// page defaults to 1 and the limit to 0, i.e. no limit.
+func parsePagination(r *http.Request) (page, limit int, err error) {
+	page = 1
+
+	if s := r.FormValue("page"); s != "" {
+		x, _ := strconv.Atoi(s)
+		if x <= 0 {
+			return 0, 0, fmt.Errorf("invalid page query: %s", s)
+		}
+
+		page = x
+	}
+
+	if s := r.FormValue("limit"); s != "" {
+		x, _ := strconv.Atoi(s)
+		if x <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit query: %s", s)
+		}
+
+		limit = x
+	}
+
+	return page, limit, nil
+}
+
 func paginate(numObjs, page, limit, defLimit int) (start, end int) {
 	if page == 0 {
 		// invalid start page